@@ -1,5 +1,7 @@
 package ast
 
+import "math/big"
+
 type Visitor interface {
 	VisitScope(n *Scope) error
 	VisitDefinition(n *Definition) error
@@ -7,11 +9,24 @@ type Visitor interface {
 	VisitSelector(n *Selector) error
 	VisitSelectorItem(n *SelectorItem) error
 	VisitApplication(n *Application) error
+	VisitConditional(n *Conditional) error
+	VisitLazy(n *Lazy) error
+	VisitFor(n *For) error
+	VisitBreak(n *Break) error
+	VisitContinue(n *Continue) error
+	VisitTry(n *Try) error
+	VisitPropagate(n *Propagate) error
+	VisitComposition(n *Composition) error
+	VisitAssignment(n *Assignment) error
 	VisitFunction(n *FunctionLiteral) error
 	VisitRecord(n *RecordLiteral) error
+	VisitMap(n *MapLiteral) error
 	VisitArray(n *ArrayLiteral) error
 	VisitSlice(n *SliceLiteral) error
+	VisitTuple(n *TupleLiteral) error
+	VisitUnpack(n *Unpack) error
 	VisitInteger(n *IntegerLiteral) error
+	VisitBigInteger(n *BigIntegerLiteral) error
 	VisitFloat(n *FloatLiteral) error
 	VisitString(n *StringLiteral) error
 	VisitCharacter(n *CharacterLiteral) error
@@ -22,25 +37,72 @@ type Node interface {
 	Accept(visitor Visitor) error
 }
 
+// The definitions and expressions of a scope, evaluated in the order they
+// are written in. A scope evaluates to its last expression, or to its last
+// definition if it has no expressions.
 type Scope struct {
-	Definitions []*Definition
-	Expressions []Expression
+	// The *Definitions and the Expressions of the scope, in source order.
+	Items []Node
 }
 
-func ScopeExpressions(expressions ...Expression) *Scope {
+func NewScope(items ...Node) *Scope {
 	return &Scope{
-		Definitions: []*Definition{},
-		Expressions: expressions,
+		Items: items,
 	}
 }
 
+func ScopeExpressions(expressions ...Expression) *Scope {
+	items := make([]Node, len(expressions))
+
+	for i, expr := range expressions {
+		items[i] = expr
+	}
+
+	return NewScope(items...)
+}
+
+// Returns the index of the item the scope evaluates to, or -1 if it is empty.
+func (s *Scope) Result() int {
+	for i := len(s.Items) - 1; i >= 0; i-- {
+		if _, ok := s.Items[i].(*Definition); !ok {
+			return i
+		}
+	}
+
+	return len(s.Items) - 1
+}
+
+// Returns the single expression which makes up the scope,
+// if it has one expression and no definitions.
+func (s *Scope) Expression() (Expression, bool) {
+	if len(s.Items) != 1 {
+		return nil, false
+	}
+
+	if _, ok := s.Items[0].(*Definition); ok {
+		return nil, false
+	}
+
+	return s.Items[0], true
+}
+
 func (s *Scope) Accept(visitor Visitor) error {
 	return visitor.VisitScope(s)
 }
 
+// The location of a local binding, resolved before evaluation. Depth is the
+// number of function frames between the reference and the binding's frame,
+// and Index is the binding's slot in that frame.
+type Binding struct {
+	Depth int
+	Index int
+}
+
 type Definition struct {
 	Identifier Identifier
 	Expression Expression
+	// The slot the definition binds, nil if it is not local to a function.
+	Binding *Binding
 }
 
 func (d *Definition) Accept(visitor Visitor) error {
@@ -66,11 +128,19 @@ func (i *Identifier) Accept(visitor Visitor) error {
 
 type Selector struct {
 	Items []*SelectorItem
+	// The binding of the first item, nil if it is not local to a function.
+	Binding *Binding
 }
 
+// An item of a selector is a field name, an index, which counts from the
+// end if it is negative, or a key computed by an expression, like `arr.(i)`.
+// Items with an end select a range, like `arr.1..3`, from the index or key
+// up to, but not including, the end.
 type SelectorItem struct {
 	Identifier *Identifier
 	Index      *IntegerLiteral
+	Expression Expression
+	End        *SelectorItem
 }
 
 func NewIdentifierSelector(ident *Identifier) *SelectorItem {
@@ -89,6 +159,21 @@ func NewIndexSelector(num *IntegerLiteral) *SelectorItem {
 	}
 }
 
+func NewComputedSelector(expr Expression) *SelectorItem {
+	return &SelectorItem{
+		Expression: expr,
+	}
+}
+
+func NewRangeSelector(start *SelectorItem, end *SelectorItem) *SelectorItem {
+	return &SelectorItem{
+		Identifier: start.Identifier,
+		Index:      start.Index,
+		Expression: start.Expression,
+		End:        end,
+	}
+}
+
 func NewSelector(identifiers ...*SelectorItem) *Selector {
 	return &Selector{
 		Items: identifiers,
@@ -99,8 +184,18 @@ func (f *Selector) Accept(visitor Visitor) error {
 	return visitor.VisitSelector(f)
 }
 
+// The first argument of an application is the function it applies.
+// Named arguments, like `port: 80`, come after the positional ones.
 type Application struct {
 	Arguments []Expression
+	Named     []*NamedArgument
+}
+
+// An argument which binds the parameter with its name, rather than the
+// parameter at its position.
+type NamedArgument struct {
+	Identifier Identifier
+	Expression Expression
 }
 
 func NewApplication(arguments ...Expression) *Application {
@@ -113,23 +208,161 @@ func (i *Application) Accept(visitor Visitor) error {
 	return visitor.VisitApplication(i)
 }
 
+// A conditional expression. The alternative is either
+// a *Scope or another *Conditional, for `else if` chains.
+type Conditional struct {
+	Condition   Expression
+	Consequence *Scope
+	Alternative Expression
+}
+
+func (c *Conditional) Accept(visitor Visitor) error {
+	return visitor.VisitConditional(c)
+}
+
+// An expression whose evaluation is deferred until its value is needed.
+type Lazy struct {
+	Expression Expression
+}
+
+func (l *Lazy) Accept(visitor Visitor) error {
+	return visitor.VisitLazy(l)
+}
+
+// A loop evaluating its body for each element of an iterable, in order.
+// The body is a function of one parameter, the element, so every iteration
+// binds the element and the definitions of the body in a frame of its own.
+type For struct {
+	Iterable Expression
+	Body     *FunctionLiteral
+}
+
+func (f *For) Accept(visitor Visitor) error {
+	return visitor.VisitFor(f)
+}
+
+// Stops the innermost loop around it.
+type Break struct{}
+
+func (b *Break) Accept(visitor Visitor) error {
+	return visitor.VisitBreak(b)
+}
+
+// Skips the rest of the body of the innermost loop around it,
+// and goes on with the next element.
+type Continue struct{}
+
+func (c *Continue) Accept(visitor Visitor) error {
+	return visitor.VisitContinue(c)
+}
+
+// An expression evaluating its body, and the handler if the body raises an
+// error which can be caught. The handler is a function of the error, nil if
+// errors are not caught, and the finalizer is evaluated last in any case,
+// nil if there is none.
+type Try struct {
+	Body      *Scope
+	Handler   *FunctionLiteral
+	Finalizer *Scope
+}
+
+func (t *Try) Accept(visitor Visitor) error {
+	return visitor.VisitTry(t)
+}
+
+// An expression evaluating to the value inside an Ok or a Some, which
+// returns the Err or the None from the function around it instead,
+// like `(parse s)?`.
+type Propagate struct {
+	Expression Expression
+}
+
+func (p *Propagate) Accept(visitor Visitor) error {
+	return visitor.VisitPropagate(p)
+}
+
+// An expression evaluating to a function which applies the first of the
+// functions to its arguments, and each of the others to the result of the
+// one before it, like `f >> g >> h`.
+type Composition struct {
+	Functions []Expression
+}
+
+func (c *Composition) Accept(visitor Visitor) error {
+	return visitor.VisitComposition(c)
+}
+
+// An expression replacing the value of the reference the target evaluates
+// to, and evaluating to the new value, like `total := (add (get total) 1)`.
+type Assignment struct {
+	Target Expression
+	Value  Expression
+}
+
+func (a *Assignment) Accept(visitor Visitor) error {
+	return visitor.VisitAssignment(a)
+}
+
 type FunctionLiteral struct {
 	Parameters []*Identifier
-	Body       *Scope
+	// The default values of the parameters, with nil for parameters which
+	// have none, or nil if none of them have one.
+	Defaults []Expression
+	// Set if the last parameter is a rest parameter, like `...rest`, which
+	// is bound to a slice of the arguments left over.
+	Variadic bool
+	// Set for the bodies of loops and the handlers of trys, which are
+	// functions, but which a ? returns through to the function around them.
+	Block bool
+	Body  *Scope
+	// The names of the function's parameters and local definitions in the
+	// order of their slots, nil if the function has not been resolved.
+	Locals []Identifier
 }
 
 func (f *FunctionLiteral) Accept(visitor Visitor) error {
 	return visitor.VisitFunction(f)
 }
 
+// Reports whether the function is always applied to as many arguments
+// as it has parameters, because none of them has a default or is a
+// rest parameter.
+func (f *FunctionLiteral) Fixed() bool {
+	return f.Defaults == nil && !f.Variadic
+}
+
+// Fields of record literals are kept in their order in the source. A
+// literal with a base, like `{ person | age: 31 }`, is an update: it
+// copies the base record, and its fields replace existing ones.
 type RecordLiteral struct {
-	Fields map[Identifier]Expression
+	// nil unless the literal is an update
+	Base   Expression
+	Fields []*RecordField
+}
+
+// A field of a record literal, or a spread, like `...person`, which
+// copies all fields of the record its expression evaluates to.
+type RecordField struct {
+	Identifier Identifier
+	Expression Expression
+	// set for spreads, which have no identifier
+	Spread bool
 }
 
 func (r *RecordLiteral) Accept(visitor Visitor) error {
 	return visitor.VisitRecord(r)
 }
 
+// Keys and values of map literals are kept in their order in the source.
+type MapLiteral struct {
+	Keys   []Expression
+	Values []Expression
+}
+
+func (m *MapLiteral) Accept(visitor Visitor) error {
+	return visitor.VisitMap(m)
+}
+
 type ArrayLiteral struct {
 	Size     uint64
 	Elements []Expression
@@ -160,6 +393,36 @@ func (s *SliceLiteral) Accept(visitor Visitor) error {
 	return visitor.VisitSlice(s)
 }
 
+type TupleLiteral struct {
+	Elements []Expression
+}
+
+func NewTupleLiteral(elements ...Expression) *TupleLiteral {
+	return &TupleLiteral{
+		Elements: elements,
+	}
+}
+
+func (t *TupleLiteral) Accept(visitor Visitor) error {
+	return visitor.VisitTuple(t)
+}
+
+// An element of a tuple which is destructured into Size names. The parser
+// turns a destructuring definition like `(q, r): (divmod 7 2)` into a
+// definition of the whole tuple, named after the pattern as it is written,
+// which no program can refer to, followed by a definition of each name as
+// an element of the tuple. Destructured parameters are turned into the
+// same definitions at the start of the function's body.
+type Unpack struct {
+	Tuple Expression
+	Index int
+	Size  int
+}
+
+func (u *Unpack) Accept(visitor Visitor) error {
+	return visitor.VisitUnpack(u)
+}
+
 type IntegerLiteral int64
 
 func NewIntegerLiteral(value int64) *IntegerLiteral {
@@ -171,6 +434,21 @@ func (n *IntegerLiteral) Accept(visitor Visitor) error {
 	return visitor.VisitInteger(n)
 }
 
+// An integer literal too large for an int64.
+type BigIntegerLiteral struct {
+	Value *big.Int
+}
+
+func NewBigIntegerLiteral(value *big.Int) *BigIntegerLiteral {
+	return &BigIntegerLiteral{
+		Value: value,
+	}
+}
+
+func (n *BigIntegerLiteral) Accept(visitor Visitor) error {
+	return visitor.VisitBigInteger(n)
+}
+
 type FloatLiteral float64
 
 func NewFloatLiteral(value float64) *FloatLiteral {
@@ -45,15 +45,7 @@ func (c *Comparator) VisitScope(expected *Scope) error {
 		return nodeTypeError("Scope")
 	}
 
-	if err := compareSlices(c, "definitions", expected.Definitions, current.Definitions); err != nil {
-		return err
-	}
-
-	if err := compareSlices(c, "expressions", expected.Expressions, current.Expressions); err != nil {
-		return err
-	}
-
-	return nil
+	return compareSlices(c, "items", expected.Items, current.Items)
 }
 
 func (c *Comparator) VisitDefinition(expected *Definition) error {
@@ -118,14 +110,29 @@ func (c *Comparator) VisitSelectorItem(expected *SelectorItem) error {
 		if err := c.Compare(expected.Identifier); err != nil {
 			return err
 		}
-	} else {
+	} else if current.Index != nil {
 		c.observe(current.Index)
 		if err := c.Compare(expected.Index); err != nil {
 			return err
 		}
+	} else {
+		c.observe(current.Expression)
+		if err := c.Compare(expected.Expression); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	if current.End == nil && expected.End == nil {
+		return nil
+	}
+
+	if current.End == nil || expected.End == nil {
+		return fmt.Errorf("expected selector range to be %t, but got %t", expected.End != nil, current.End != nil)
+	}
+
+	c.observe(current.End)
+
+	return c.Compare(expected.End)
 }
 
 func (c *Comparator) VisitApplication(expected *Application) error {
@@ -139,9 +146,169 @@ func (c *Comparator) VisitApplication(expected *Application) error {
 		return err
 	}
 
+	if err := compareNamed(c, expected.Named, current.Named); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Comparator) VisitConditional(expected *Conditional) error {
+	current, ok := c.current.(*Conditional)
+
+	if !ok {
+		return nodeTypeError("Conditional")
+	}
+
+	c.observe(current.Condition)
+
+	if err := c.Compare(expected.Condition); err != nil {
+		return err
+	}
+
+	c.observe(current.Consequence)
+
+	if err := c.Compare(expected.Consequence); err != nil {
+		return err
+	}
+
+	c.observe(current.Alternative)
+
+	if err := c.Compare(expected.Alternative); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Comparator) VisitLazy(expected *Lazy) error {
+	current, ok := c.current.(*Lazy)
+
+	if !ok {
+		return nodeTypeError("Lazy")
+	}
+
+	c.observe(current.Expression)
+
+	if err := c.Compare(expected.Expression); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Comparator) VisitFor(expected *For) error {
+	current, ok := c.current.(*For)
+
+	if !ok {
+		return nodeTypeError("For")
+	}
+
+	c.observe(current.Iterable)
+
+	if err := c.Compare(expected.Iterable); err != nil {
+		return err
+	}
+
+	c.observe(current.Body)
+
+	return c.Compare(expected.Body)
+}
+
+func (c *Comparator) VisitBreak(expected *Break) error {
+	if _, ok := c.current.(*Break); !ok {
+		return nodeTypeError("Break")
+	}
+
+	return nil
+}
+
+func (c *Comparator) VisitContinue(expected *Continue) error {
+	if _, ok := c.current.(*Continue); !ok {
+		return nodeTypeError("Continue")
+	}
+
+	return nil
+}
+
+func (c *Comparator) VisitTry(expected *Try) error {
+	current, ok := c.current.(*Try)
+
+	if !ok {
+		return nodeTypeError("Try")
+	}
+
+	if (expected.Handler == nil) != (current.Handler == nil) {
+		return fmt.Errorf("expected try handler to be %t, but got %t", expected.Handler != nil, current.Handler != nil)
+	}
+
+	if (expected.Finalizer == nil) != (current.Finalizer == nil) {
+		return fmt.Errorf("expected try finalizer to be %t, but got %t", expected.Finalizer != nil, current.Finalizer != nil)
+	}
+
+	c.observe(current.Body)
+
+	if err := c.Compare(expected.Body); err != nil {
+		return err
+	}
+
+	if expected.Handler != nil {
+		c.observe(current.Handler)
+
+		if err := c.Compare(expected.Handler); err != nil {
+			return err
+		}
+	}
+
+	if expected.Finalizer != nil {
+		c.observe(current.Finalizer)
+
+		return c.Compare(expected.Finalizer)
+	}
+
 	return nil
 }
 
+func (c *Comparator) VisitPropagate(expected *Propagate) error {
+	current, ok := c.current.(*Propagate)
+
+	if !ok {
+		return nodeTypeError("Propagate")
+	}
+
+	c.observe(current.Expression)
+
+	return c.Compare(expected.Expression)
+}
+
+func (c *Comparator) VisitComposition(expected *Composition) error {
+	current, ok := c.current.(*Composition)
+
+	if !ok {
+		return nodeTypeError("Composition")
+	}
+
+	return compareSlices(c, "functions", expected.Functions, current.Functions)
+}
+
+func (c *Comparator) VisitAssignment(expected *Assignment) error {
+	current, ok := c.current.(*Assignment)
+
+	if !ok {
+		return nodeTypeError("Assignment")
+	}
+
+	c.observe(current.Target)
+
+	if err := c.Compare(expected.Target); err != nil {
+		return err
+	}
+
+	c.observe(current.Value)
+
+	return c.Compare(expected.Value)
+}
+
 func (c *Comparator) VisitFunction(expected *FunctionLiteral) error {
 	current, ok := c.current.(*FunctionLiteral)
 
@@ -153,6 +320,18 @@ func (c *Comparator) VisitFunction(expected *FunctionLiteral) error {
 		return err
 	}
 
+	if err := compareSlices(c, "defaults", expected.Defaults, current.Defaults); err != nil {
+		return err
+	}
+
+	if expected.Variadic != current.Variadic {
+		return fmt.Errorf("expected variadic to be %t, but got %t", expected.Variadic, current.Variadic)
+	}
+
+	if expected.Block != current.Block {
+		return fmt.Errorf("expected block to be %t, but got %t", expected.Block, current.Block)
+	}
+
 	c.observe(current.Body)
 
 	if err := c.Compare(expected.Body); err != nil {
@@ -169,7 +348,31 @@ func (c *Comparator) VisitRecord(expected *RecordLiteral) error {
 		return nodeTypeError("RecordLiteral")
 	}
 
-	if err := compareMaps(c, expected.Fields, current.Fields); err != nil {
+	c.observe(current.Base)
+
+	if err := c.Compare(expected.Base); err != nil {
+		return err
+	}
+
+	if err := compareFields(c, expected.Fields, current.Fields); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Comparator) VisitMap(expected *MapLiteral) error {
+	current, ok := c.current.(*MapLiteral)
+
+	if !ok {
+		return nodeTypeError("MapLiteral")
+	}
+
+	if err := compareSlices(c, "keys", expected.Keys, current.Keys); err != nil {
+		return err
+	}
+
+	if err := compareSlices(c, "values", expected.Values, current.Values); err != nil {
 		return err
 	}
 
@@ -194,6 +397,36 @@ func (c *Comparator) VisitArray(expected *ArrayLiteral) error {
 	return nil
 }
 
+func (c *Comparator) VisitTuple(expected *TupleLiteral) error {
+	current, ok := c.current.(*TupleLiteral)
+
+	if !ok {
+		return nodeTypeError("TupleLiteral")
+	}
+
+	if err := compareSlices(c, "elements", expected.Elements, current.Elements); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Comparator) VisitUnpack(expected *Unpack) error {
+	current, ok := c.current.(*Unpack)
+
+	if !ok {
+		return nodeTypeError("Unpack")
+	}
+
+	if expected.Index != current.Index || expected.Size != current.Size {
+		return fmt.Errorf("expected element %d of %d, but got element %d of %d", expected.Index, expected.Size, current.Index, current.Size)
+	}
+
+	c.observe(current.Tuple)
+
+	return c.Compare(expected.Tuple)
+}
+
 func (c *Comparator) VisitSlice(expected *SliceLiteral) error {
 	current, ok := c.current.(*SliceLiteral)
 
@@ -222,6 +455,20 @@ func (c *Comparator) VisitInteger(expected *IntegerLiteral) error {
 	return nil
 }
 
+func (c *Comparator) VisitBigInteger(expected *BigIntegerLiteral) error {
+	current, ok := c.current.(*BigIntegerLiteral)
+
+	if !ok {
+		return nodeTypeError("BigIntegerLiteral")
+	}
+
+	if current.Value.Cmp(expected.Value) != 0 {
+		return fmt.Errorf("expected `%s`, but got `%s`", expected.Value, current.Value)
+	}
+
+	return nil
+}
+
 func (c *Comparator) VisitFloat(expected *FloatLiteral) error {
 	current, ok := c.current.(*FloatLiteral)
 
@@ -300,23 +547,46 @@ func compareSlices[T Node](c *Comparator, what string, expected []T, current []T
 	return nil
 }
 
-func compareMaps[T Node](c *Comparator, expected map[Identifier]T, current map[Identifier]T) error {
+func compareFields(c *Comparator, expected []*RecordField, current []*RecordField) error {
 	if len(expected) != len(current) {
 		return fmt.Errorf("expected %d fields, but got %d", len(expected), len(current))
 	}
 
-	for ident, expr := range expected {
-		otherExpr, ok := current[ident]
+	for i, field := range expected {
+		if field.Identifier != current[i].Identifier {
+			return fmt.Errorf("expected field `%s`, but got `%s`", field.Identifier, current[i].Identifier)
+		}
+
+		if field.Spread != current[i].Spread {
+			return fmt.Errorf("expected spread to be %t, but got %t", field.Spread, current[i].Spread)
+		}
+
+		c.observe(current[i].Expression)
+
+		if err := c.Compare(field.Expression); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func compareNamed(c *Comparator, expected []*NamedArgument, current []*NamedArgument) error {
+	if len(expected) != len(current) {
+		return fmt.Errorf("expected %d named arguments, but got %d", len(expected), len(current))
+	}
 
-		if !ok {
-			return fmt.Errorf("field `%s` not found", ident)
+	for i, arg := range expected {
+		if arg.Identifier != current[i].Identifier {
+			return fmt.Errorf("expected argument `%s`, but got `%s`", arg.Identifier, current[i].Identifier)
 		}
 
-		c.observe(otherExpr)
+		c.observe(current[i].Expression)
 
-		if err := c.Compare(expr); err != nil {
+		if err := c.Compare(arg.Expression); err != nil {
 			return err
 		}
 	}
+
 	return nil
 }
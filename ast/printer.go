@@ -32,26 +32,22 @@ func (p *Printer) writeln() {
 /*** Visitor Methods ***/
 
 func (p *Printer) VisitScope(n *Scope) error {
-	for i, d := range n.Definitions {
-		if err := d.Accept(p); err != nil {
-			return err
-		}
-		if i != len(n.Definitions)-1 {
-			p.write(" ")
+	for i, item := range n.Items {
+		if i > 0 {
+			// expressions go on a new line after definitions
+			_, before := n.Items[i-1].(*Definition)
+			_, current := item.(*Definition)
+
+			if before && !current {
+				p.writeln()
+			} else {
+				p.write(" ")
+			}
 		}
-	}
-
-	if len(n.Definitions) > 0 && len(n.Expressions) > 0 {
-		p.writeln()
-	}
 
-	for i, e := range n.Expressions {
-		if err := e.Accept(p); err != nil {
+		if err := item.Accept(p); err != nil {
 			return err
 		}
-		if i != len(n.Expressions)-1 {
-			p.write(" ")
-		}
 	}
 
 	return nil
@@ -107,8 +103,21 @@ func (p *Printer) VisitSelector(n *Selector) error {
 func (p *Printer) VisitSelectorItem(n *SelectorItem) error {
 	if n.Identifier != nil {
 		p.write(string(*n.Identifier))
-	} else {
+	} else if n.Index != nil {
 		p.write(fmt.Sprintf("%d", *n.Index))
+	} else {
+		p.write("(")
+
+		if err := n.Expression.Accept(p); err != nil {
+			return err
+		}
+
+		p.write(")")
+	}
+
+	if n.End != nil {
+		p.write("..")
+		return n.End.Accept(p)
 	}
 
 	return nil
@@ -125,16 +134,173 @@ func (p *Printer) VisitApplication(n *Application) error {
 		p.write(" ")
 	}
 
+	for _, a := range n.Named {
+		p.write(string(a.Identifier))
+		p.write(": ")
+
+		if err := a.Expression.Accept(p); err != nil {
+			return err
+		}
+
+		p.write(" ")
+	}
+
 	p.write(")")
 
 	return nil
 }
 
+func (p *Printer) VisitConditional(n *Conditional) error {
+	p.write("if ")
+
+	if err := n.Condition.Accept(p); err != nil {
+		return err
+	}
+
+	p.write(" { ")
+
+	if err := n.Consequence.Accept(p); err != nil {
+		return err
+	}
+
+	p.write(" } else ")
+
+	if _, is_scope := n.Alternative.(*Scope); is_scope {
+		p.write("{ ")
+
+		if err := n.Alternative.Accept(p); err != nil {
+			return err
+		}
+
+		p.write(" }")
+	} else if err := n.Alternative.Accept(p); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *Printer) VisitLazy(n *Lazy) error {
+	p.write("lazy ")
+	return n.Expression.Accept(p)
+}
+
+func (p *Printer) VisitFor(n *For) error {
+	p.write("for ")
+	p.write(string(*n.Body.Parameters[0]))
+	p.write(" in ")
+
+	if err := n.Iterable.Accept(p); err != nil {
+		return err
+	}
+
+	p.write(" { ")
+
+	if err := n.Body.Body.Accept(p); err != nil {
+		return err
+	}
+
+	p.write(" }")
+
+	return nil
+}
+
+func (p *Printer) VisitBreak(n *Break) error {
+	p.write("break")
+	return nil
+}
+
+func (p *Printer) VisitContinue(n *Continue) error {
+	p.write("continue")
+	return nil
+}
+
+func (p *Printer) VisitTry(n *Try) error {
+	p.write("try { ")
+
+	if err := n.Body.Accept(p); err != nil {
+		return err
+	}
+
+	p.write(" }")
+
+	if n.Handler != nil {
+		p.write(" catch ")
+		p.write(string(*n.Handler.Parameters[0]))
+		p.write(" { ")
+
+		if err := n.Handler.Body.Accept(p); err != nil {
+			return err
+		}
+
+		p.write(" }")
+	}
+
+	if n.Finalizer != nil {
+		p.write(" finally { ")
+
+		if err := n.Finalizer.Accept(p); err != nil {
+			return err
+		}
+
+		p.write(" }")
+	}
+
+	return nil
+}
+
+func (p *Printer) VisitPropagate(n *Propagate) error {
+	if err := n.Expression.Accept(p); err != nil {
+		return err
+	}
+
+	p.write("?")
+
+	return nil
+}
+
+func (p *Printer) VisitAssignment(n *Assignment) error {
+	if err := n.Target.Accept(p); err != nil {
+		return err
+	}
+
+	p.write(" := ")
+
+	return n.Value.Accept(p)
+}
+
+func (p *Printer) VisitComposition(n *Composition) error {
+	for i, expr := range n.Functions {
+		if i > 0 {
+			p.write(" >> ")
+		}
+
+		if err := expr.Accept(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (p *Printer) VisitFunction(n *FunctionLiteral) error {
 	p.write("\\")
 
-	for _, param := range n.Parameters {
+	for i, param := range n.Parameters {
+		if n.Variadic && i == len(n.Parameters)-1 {
+			p.write("...")
+		}
+
 		p.write(string(*param))
+
+		if n.Defaults != nil && n.Defaults[i] != nil {
+			p.write(": ")
+
+			if err := n.Defaults[i].Accept(p); err != nil {
+				return err
+			}
+		}
+
 		p.write(" ")
 	}
 
@@ -152,11 +318,45 @@ func (p *Printer) VisitFunction(n *FunctionLiteral) error {
 func (p *Printer) VisitRecord(n *RecordLiteral) error {
 	p.write("{ ")
 
-	for ident, expr := range n.Fields {
-		p.write(string(ident))
+	if n.Base != nil {
+		if err := n.Base.Accept(p); err != nil {
+			return err
+		}
+
+		p.write(" | ")
+	}
+
+	for _, field := range n.Fields {
+		if field.Spread {
+			p.write("...")
+		} else {
+			p.write(string(field.Identifier))
+			p.write(": ")
+		}
+
+		if err := field.Expression.Accept(p); err != nil {
+			return err
+		}
+
+		p.write(" ")
+	}
+
+	p.write("}")
+
+	return nil
+}
+
+func (p *Printer) VisitMap(n *MapLiteral) error {
+	p.write("%{ ")
+
+	for i, key := range n.Keys {
+		if err := key.Accept(p); err != nil {
+			return err
+		}
+
 		p.write(": ")
 
-		if err := expr.Accept(p); err != nil {
+		if err := n.Values[i].Accept(p); err != nil {
 			return err
 		}
 
@@ -200,11 +400,48 @@ func (p *Printer) VisitSlice(n *SliceLiteral) error {
 	return nil
 }
 
+func (p *Printer) VisitTuple(n *TupleLiteral) error {
+	p.write("(")
+
+	for i, expr := range n.Elements {
+		if i > 0 {
+			p.write(", ")
+		}
+
+		if err := expr.Accept(p); err != nil {
+			return err
+		}
+	}
+
+	if len(n.Elements) == 1 {
+		p.write(",")
+	}
+
+	p.write(")")
+
+	return nil
+}
+
+func (p *Printer) VisitUnpack(n *Unpack) error {
+	if err := n.Tuple.Accept(p); err != nil {
+		return err
+	}
+
+	p.write(fmt.Sprintf(".%d", n.Index))
+
+	return nil
+}
+
 func (p *Printer) VisitInteger(n *IntegerLiteral) error {
 	p.write(fmt.Sprintf("%d", *n))
 	return nil
 }
 
+func (p *Printer) VisitBigInteger(n *BigIntegerLiteral) error {
+	p.write(n.Value.String())
+	return nil
+}
+
 func (p *Printer) VisitFloat(n *FloatLiteral) error {
 	p.write(fmt.Sprintf("%g", *n))
 	return nil
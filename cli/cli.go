@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"strings"
+
 	"raiton/cli/repl"
+	"raiton/optimizer"
 
 	"github.com/urfave/cli/v2"
 )
@@ -20,6 +23,16 @@ func New() Cli {
 				Name:   "repl",
 				Usage:  "start the REPL",
 				Action: repl.Run,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "lazy",
+						Usage: "evaluate definitions and arguments only when needed",
+					},
+					&cli.BoolFlag{
+						Name:  "compile",
+						Usage: "compile expressions into closures before evaluating them",
+					},
+				},
 			},
 			{
 				Name:      "tokenize",
@@ -32,6 +45,16 @@ func New() Cli {
 				Usage:     "parse the given file and check for errors",
 				ArgsUsage: "[file path]",
 				Action:    parse,
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "opt",
+						Usage: "optimize with the given passes (" + strings.Join(optimizer.Passes, ", ") + "), or `all`",
+					},
+					&cli.BoolFlag{
+						Name:  "dump",
+						Usage: "print the program after every optimization pass",
+					},
+				},
 			},
 		},
 	}
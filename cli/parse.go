@@ -5,9 +5,11 @@ import (
 	"io"
 	"os"
 	"path"
+	"slices"
 
 	"raiton/ast"
 	"raiton/lexer"
+	"raiton/optimizer"
 	"raiton/parser"
 	"github.com/urfave/cli/v2"
 )
@@ -35,7 +37,13 @@ func parse(ctx *cli.Context) error {
 	program, err := p.Parse()
 
 	if err != nil {
-		fmt.Fprintf(ctx.App.ErrWriter, "parse error: %s\n", err)
+		return cli.Exit(fmt.Sprintf("parse error: %s", err), 1)
+	}
+
+	if ctx.IsSet("opt") {
+		if program, err = optimize(ctx, program); err != nil {
+			return err
+		}
 	}
 
 	printer := ast.NewPrinter(program)
@@ -44,3 +52,21 @@ func parse(ctx *cli.Context) error {
 
 	return nil
 }
+
+func optimize(ctx *cli.Context, program ast.Node) (ast.Node, error) {
+	opts := []optimizer.Option{}
+
+	if passes := ctx.StringSlice("opt"); !slices.Contains(passes, "all") {
+		opts = append(opts, optimizer.WithPasses(passes...))
+	}
+
+	if ctx.Bool("dump") {
+		opts = append(opts, optimizer.WithDump(func(pass string, node ast.Node) {
+			fmt.Fprintf(ctx.App.Writer, "# after %s\n%s\n\n", pass, ast.NewPrinter(node).String())
+		}))
+	}
+
+	o := optimizer.New(opts...)
+
+	return o.Optimize(program)
+}
@@ -1,6 +1,9 @@
 package repl
 
 import (
+	"context"
+
+	"raiton/compiler"
 	"raiton/evaluator"
 	"raiton/lexer"
 	"raiton/parser"
@@ -8,7 +11,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func (r *repl) evaluateSource(input string) tea.Cmd {
+func (r *repl) evaluateSource(ctx context.Context, input string) tea.Cmd {
 	return func() tea.Msg {
 		lex := lexer.New(input)
 		par := parser.New(&lex)
@@ -19,9 +22,25 @@ func (r *repl) evaluateSource(input string) tea.Cmd {
 			return errorMsg(err)
 		}
 
-		eval := evaluator.New(r.env)
+		opts := []evaluator.Option{
+			evaluator.WithMaxDepth(evaluator.DefaultMaxDepth),
+		}
+
+		if r.lazy {
+			opts = append(opts, evaluator.WithLazyEvaluation())
+		}
+
+		var backend evaluator.Backend
+
+		if r.compiled {
+			c := compiler.New(r.env, opts...)
+			backend = &c
+		} else {
+			e := evaluator.New(r.env, opts...)
+			backend = &e
+		}
 
-		result, err := eval.Evaluate(node)
+		result, err := backend.EvaluateContext(ctx, node)
 
 		if err != nil {
 			return errorMsg(err)
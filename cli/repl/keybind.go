@@ -9,6 +9,7 @@ type keyMap struct {
 	Up       key.Binding
 	Down     key.Binding
 	Evaluate key.Binding
+	Cancel   key.Binding
 	Quit     key.Binding
 }
 
@@ -26,6 +27,10 @@ func defaultKeyMap() keyMap {
 			key.WithKeys("enter"),
 			key.WithHelp("enter", "evaluate"),
 		),
+		Cancel: key.NewBinding(
+			key.WithKeys("ctrl+c"),
+			key.WithHelp("ctrl+c", "cancel evaluation"),
+		),
 		Quit: key.NewBinding(
 			key.WithKeys("ctrl+c"),
 			key.WithHelp("ctrl+c", "quit"),
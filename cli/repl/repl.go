@@ -1,6 +1,7 @@
 package repl
 
 import (
+	"context"
 	"fmt"
 	"raiton/object"
 	"strings"
@@ -23,6 +24,10 @@ type repl struct {
 	textInput textinput.Model
 	history   history
 	env       *object.Environment
+	lazy      bool
+	compiled  bool
+	// cancels the evaluation in progress, nil if there is none
+	cancel context.CancelFunc
 }
 
 type errorMsg error
@@ -34,7 +39,7 @@ var dimmedStyle = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(8))
 var expressionStyle = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(7))
 var errorStyle = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(9))
 
-func initialModel() *repl {
+func initialModel(lazy bool, compiled bool) *repl {
 	vp := viewport.New(0, 0)
 	vp.KeyMap = viewportKeyMap()
 
@@ -53,6 +58,8 @@ func initialModel() *repl {
 		textInput: ti,
 		env:       object.NewEnvironment(),
 		history:   newHistory(),
+		lazy:      lazy,
+		compiled:  compiled,
 	}
 }
 
@@ -73,6 +80,9 @@ func (m *repl) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.computeViewportHeight()
 	case tea.KeyMsg:
 		switch {
+		case m.cancel != nil && key.Matches(msg, m.keys.Cancel):
+			m.cancel()
+			return m, nil
 		case key.Matches(msg, m.keys.Quit):
 			return m, tea.Quit
 		case key.Matches(msg, m.keys.Up):
@@ -83,9 +93,11 @@ func (m *repl) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.evaluate(msg)
 		}
 	case resultMsg:
+		m.finishEvaluation()
 		m.addLine(msg.Inspect())
 		return m, nil
 	case errorMsg:
+		m.finishEvaluation()
 		m.addLine(errorStyle.Render(msg.Error()))
 		return m, nil
 	}
@@ -97,6 +109,11 @@ func (m *repl) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *repl) evaluate(msg tea.Msg) (*repl, tea.Cmd) {
+	if m.cancel != nil {
+		// the environment is not safe to share between evaluations
+		return m, nil
+	}
+
 	rawInput := m.textInput.Value()
 	input := strings.TrimSpace(rawInput)
 
@@ -115,7 +132,17 @@ func (m *repl) evaluate(msg tea.Msg) (*repl, tea.Cmd) {
 
 	m.history.add(input)
 
-	return m, m.evaluateSource(input)
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	return m, m.evaluateSource(ctx, input)
+}
+
+func (m *repl) finishEvaluation() {
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
 }
 
 func (m *repl) previousItem(msg tea.Msg) (*repl, tea.Cmd) {
@@ -159,13 +186,17 @@ func (m *repl) View() string {
 
 	s.WriteString(m.textInput.View())
 	s.WriteString("\n")
-	s.WriteString(dimmedStyle.Render("(type 'exit' or ctrl+c to quit)"))
+	if m.cancel != nil {
+		s.WriteString(dimmedStyle.Render("(evaluating; ctrl+c to cancel)"))
+	} else {
+		s.WriteString(dimmedStyle.Render("(type 'exit' or ctrl+c to quit)"))
+	}
 
 	return fmt.Sprintf(s.String())
 }
 
 func Run(ctx *cli.Context) error {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	p := tea.NewProgram(initialModel(ctx.Bool("lazy"), ctx.Bool("compile")), tea.WithAltScreen(), tea.WithMouseCellMotion())
 
 	if _, err := p.Run(); err != nil {
 		return err
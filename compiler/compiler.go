@@ -0,0 +1,1072 @@
+// Package compiler evaluates programs by compiling every node of the syntax
+// tree once into a Go closure, so running a program doesn't dispatch on node
+// types or push its intermediate results onto a stack. It is configured with
+// the same options and produces the same results as the evaluator.
+package compiler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"raiton/ast"
+	"raiton/evaluator"
+	"raiton/object"
+	"raiton/resolver"
+)
+
+// Frame holds the bindings compiled code is run in.
+type Frame = object.Environment
+
+type code func(f *Frame) (object.Object, error)
+
+type Compiler struct {
+	env    *Frame
+	config evaluator.Config
+	meter  evaluator.Meter
+	// code compiled by the last visited node
+	code code
+	// set right before compiling an expression in tail position
+	tail bool
+	// function bodies, lazy expressions and default values are compiled
+	// once, the first time a function literal or thunk is compiled or run
+	bodies map[*ast.Scope]code
+	thunks map[ast.Expression]code
+}
+
+func New(env *Frame, opts ...evaluator.Option) Compiler {
+	config := evaluator.NewConfig(opts...)
+
+	return Compiler{
+		env:    env,
+		config: config,
+		meter:  evaluator.NewMeter(config),
+		bodies: map[*ast.Scope]code{},
+		thunks: map[ast.Expression]code{},
+	}
+}
+
+func (c *Compiler) Evaluate(node ast.Node) (object.Object, error) {
+	return c.EvaluateContext(context.Background(), node)
+}
+
+// Compiles the node and runs it, stopping with evaluator.ErrCanceled
+// or evaluator.ErrTimeout if the context is done before it completes.
+func (c *Compiler) EvaluateContext(ctx context.Context, node ast.Node) (object.Object, error) {
+	ctx, cancel := c.meter.Start(ctx)
+	defer cancel()
+
+	if !c.config.Dynamic {
+		r := resolver.New()
+
+		if err := r.Resolve(node); err != nil {
+			return nil, err
+		}
+	}
+
+	run, err := c.compile(node, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := run(c.env)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.force(result)
+}
+
+// Compiles the node, marking it as being in tail position if tail is set.
+func (c *Compiler) compile(node ast.Node, tail bool) (code, error) {
+	c.tail = tail
+
+	if err := node.Accept(c); err != nil {
+		return nil, err
+	}
+
+	c.tail = false
+
+	return c.code, nil
+}
+
+func (c *Compiler) takeTail() bool {
+	tail := c.tail
+	c.tail = false
+	return tail
+}
+
+func (c *Compiler) compileAll(nodes []ast.Expression) ([]code, error) {
+	codes := make([]code, len(nodes))
+
+	for i, node := range nodes {
+		run, err := c.compile(node, false)
+
+		if err != nil {
+			return nil, err
+		}
+
+		codes[i] = run
+	}
+
+	return codes, nil
+}
+
+// Compiles the expressions of collection literals, whose elements are forced.
+func (c *Compiler) compileElements(nodes []ast.Expression) (func(f *Frame) ([]object.Object, error), error) {
+	codes, err := c.compileAll(nodes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return func(f *Frame) ([]object.Object, error) {
+		objs := make([]object.Object, len(codes))
+
+		for i, run := range codes {
+			obj, err := run(f)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if objs[i], err = c.force(obj); err != nil {
+				return nil, err
+			}
+		}
+
+		return objs, nil
+	}, nil
+}
+
+// Looks the name up in the frame, and then among the builtins.
+func (c *Compiler) lookup(f *Frame, ident string) (object.Object, error) {
+	if obj, ok := f.Lookup(ident); ok {
+		return c.force(obj)
+	}
+
+	if obj, ok := c.config.Builtins[ident]; ok {
+		return obj, nil
+	}
+
+	return nil, evaluator.Errorf(evaluator.KindName, "'%s' not defined", ident)
+}
+
+/*** Visitor Methods ***/
+
+func (c *Compiler) VisitScope(s *ast.Scope) error {
+	tail := c.takeTail()
+
+	items := make([]code, 0, len(s.Items))
+	last := s.Result()
+
+	for i, item := range s.Items {
+		// the result is only in tail position if nothing is evaluated after it
+		run, err := c.compile(item, tail && i == last && i == len(s.Items)-1)
+
+		if err != nil {
+			return err
+		}
+
+		items = append(items, run)
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		var result object.Object
+
+		for i, run := range items {
+			if err := c.meter.Step(); err != nil {
+				return nil, err
+			}
+
+			value, err := run(f)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if i == last {
+				result = value
+			}
+		}
+
+		if result == nil {
+			return nil, fmt.Errorf("expected a value on object stack")
+		}
+
+		return result, nil
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitDefinition(d *ast.Definition) error {
+	ident := string(d.Identifier)
+	binding := d.Binding
+
+	value, err := c.compileDelayed(d.Expression)
+
+	if err != nil {
+		return err
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		obj, err := value(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if binding != nil {
+			return f.Set(binding.Index, obj), nil
+		}
+
+		return f.Define(ident, obj), nil
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitIdentifier(i *ast.Identifier) error {
+	ident := string(*i)
+
+	c.code = func(f *Frame) (object.Object, error) {
+		return c.lookup(f, ident)
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitSelector(s *ast.Selector) error {
+	if len(s.Items) < 1 || s.Items[0].Identifier == nil {
+		return fmt.Errorf("expected first selector item to be an identifier")
+	}
+
+	ident := string(*s.Items[0].Identifier)
+	binding := s.Binding
+	items := make([]func(f *Frame, obj object.Object) (object.Object, error), len(s.Items)-1)
+
+	for i, item := range s.Items[1:] {
+		code, err := c.compileItem(item)
+
+		if err != nil {
+			return err
+		}
+
+		items[i] = code
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		var obj object.Object
+		var err error
+
+		if binding != nil {
+			obj = f.Get(binding.Depth, binding.Index)
+		}
+
+		// a slot is not assigned if its definition has not been run yet,
+		// in which case the name may refer to a binding of an enclosing scope
+		if obj == nil {
+			obj, err = c.lookup(f, ident)
+		} else {
+			obj, err = c.force(obj)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			if obj, err = item(f, obj); err != nil {
+				return nil, err
+			}
+		}
+
+		return obj, nil
+	}
+
+	return nil
+}
+
+// Compiles a selector item into a function which selects it from an object.
+func (c *Compiler) compileItem(i *ast.SelectorItem) (func(f *Frame, obj object.Object) (object.Object, error), error) {
+	key, err := c.compileKey(i)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if i.End == nil {
+		return func(f *Frame, obj object.Object) (object.Object, error) {
+			k, err := key(f)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return evaluator.Select(obj, k)
+		}, nil
+	}
+
+	end, err := c.compileKey(i.End)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return func(f *Frame, obj object.Object) (object.Object, error) {
+		start, err := key(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := end(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return evaluator.SelectRange(obj, start, e)
+	}, nil
+}
+
+// Compiles the key of a selector item, which is known ahead of time unless it is computed.
+func (c *Compiler) compileKey(i *ast.SelectorItem) (code, error) {
+	if i.Expression == nil {
+		key := evaluator.ItemKey(i)
+
+		return func(*Frame) (object.Object, error) {
+			return key, nil
+		}, nil
+	}
+
+	code, err := c.compile(i.Expression, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return func(f *Frame) (object.Object, error) {
+		obj, err := code(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return c.force(obj)
+	}, nil
+}
+
+func (c *Compiler) VisitSelectorItem(i *ast.SelectorItem) error {
+	return fmt.Errorf("selector items are compiled as part of their selector")
+}
+
+func (c *Compiler) VisitApplication(a *ast.Application) error {
+	tail := c.takeTail()
+
+	if len(a.Arguments) < 1 {
+		return fmt.Errorf("expected at least one expression")
+	}
+
+	callee, err := c.compile(a.Arguments[0], false)
+
+	if err != nil {
+		return err
+	}
+
+	strict, err := c.compileAll(a.Arguments[1:])
+
+	if err != nil {
+		return err
+	}
+
+	// arguments of functions are delayed in lazy mode,
+	// while builtins force theirs, so they are never delayed
+	delayed := make([]code, len(strict))
+
+	for i, arg := range a.Arguments[1:] {
+		delayed[i] = c.delayed(arg, strict[i])
+	}
+
+	arguments := func(f *Frame, codes []code) ([]object.Object, error) {
+		args := make([]object.Object, len(codes))
+
+		for i, run := range codes {
+			arg, err := run(f)
+
+			if err != nil {
+				return nil, err
+			}
+
+			args[i] = arg
+		}
+
+		return args, nil
+	}
+
+	// named arguments are only taken by functions, so they are delayed as well
+	named := make([]code, len(a.Named))
+
+	for i, arg := range a.Named {
+		if named[i], err = c.compileDelayed(arg.Expression); err != nil {
+			return err
+		}
+	}
+
+	applyNamed := func(f *Frame, fn object.Object, args []object.Object) (object.Object, error) {
+		values, err := arguments(f, named)
+
+		if err != nil {
+			return nil, err
+		}
+
+		namedArgs := make([]evaluator.NamedArgument, len(values))
+
+		for i, value := range values {
+			namedArgs[i] = evaluator.NamedArgument{Name: string(a.Named[i].Identifier), Value: value}
+		}
+
+		return c.applyNamed(fn, args, namedArgs)
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		if err := c.meter.Step(); err != nil {
+			return nil, err
+		}
+
+		obj, err := callee(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if obj, err = c.force(obj); err != nil {
+			return nil, err
+		}
+
+		if obj.Type() == object.BUILTIN && len(named) > 0 {
+			return nil, evaluator.Errorf(evaluator.KindArgument, "builtin functions don't take named arguments")
+		}
+
+		switch fn := obj.(type) {
+		case *object.Function:
+			args, err := arguments(f, delayed)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if len(named) > 0 {
+				return applyNamed(f, fn, args)
+			}
+
+			if tail {
+				return &evaluator.TailCall{Function: fn, Arguments: args}, nil
+			}
+
+			return c.applyFunction(fn, args, nil)
+		case *object.Builtin:
+			args, err := arguments(f, strict)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return c.apply(fn, args...)
+		case *object.Partial:
+			codes := strict
+
+			if fn.Type() == object.FUNCTION {
+				codes = delayed
+			}
+
+			args, err := arguments(f, codes)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if len(named) > 0 {
+				return applyNamed(f, fn, args)
+			}
+
+			return c.apply(fn, args...)
+		default:
+			return obj, nil
+		}
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitConditional(cond *ast.Conditional) error {
+	tail := c.takeTail()
+
+	condition, err := c.compile(cond.Condition, false)
+
+	if err != nil {
+		return err
+	}
+
+	consequence, err := c.compile(cond.Consequence, tail)
+
+	if err != nil {
+		return err
+	}
+
+	alternative, err := c.compile(cond.Alternative, tail)
+
+	if err != nil {
+		return err
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		obj, err := condition(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if obj, err = c.force(obj); err != nil {
+			return nil, err
+		}
+
+		value, ok := obj.(*object.Boolean)
+
+		if !ok {
+			return nil, evaluator.Errorf(evaluator.KindType, "expected condition to be boolean, but got %s", obj.Type())
+		}
+
+		if value.Value {
+			return consequence(f)
+		}
+
+		return alternative(f)
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitLazy(l *ast.Lazy) error {
+	c.code = c.delay(l.Expression)
+	return nil
+}
+
+func (c *Compiler) VisitFor(l *ast.For) error {
+	iterable, err := c.compile(l.Iterable, false)
+
+	if err != nil {
+		return err
+	}
+
+	function, err := c.compile(l.Body, false)
+
+	if err != nil {
+		return err
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		collection, err := iterable(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if collection, err = c.force(collection); err != nil {
+			return nil, err
+		}
+
+		body, err := function(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return evaluator.Loop(collection, func(element object.Object) error {
+			// the body is forced, since it is evaluated for its effects
+			_, err := c.Apply(body, element)
+			return err
+		})
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitBreak(n *ast.Break) error {
+	c.code = func(f *Frame) (object.Object, error) {
+		return nil, evaluator.ErrBreak
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitContinue(n *ast.Continue) error {
+	c.code = func(f *Frame) (object.Object, error) {
+		return nil, evaluator.ErrContinue
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitPropagate(p *ast.Propagate) error {
+	expression, err := c.compile(p.Expression, false)
+
+	if err != nil {
+		return err
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		obj, err := expression(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if obj, err = c.force(obj); err != nil {
+			return nil, err
+		}
+
+		return evaluator.Propagate(obj, f)
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitAssignment(n *ast.Assignment) error {
+	operands, err := c.compileElements([]ast.Expression{n.Target, n.Value})
+
+	if err != nil {
+		return err
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		objs, err := operands(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return evaluator.Assign(objs[0], objs[1])
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitComposition(n *ast.Composition) error {
+	fns, err := c.compileElements(n.Functions)
+
+	if err != nil {
+		return err
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		objs, err := fns(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return evaluator.Compose(objs...)
+	}
+
+	return nil
+}
+
+// Compiles a try. Nothing in it is in tail position, since the calls
+// made in it have to return to it for their errors to be caught.
+func (c *Compiler) VisitTry(t *ast.Try) error {
+	body, err := c.compile(t.Body, false)
+
+	if err != nil {
+		return err
+	}
+
+	var handler, finalizer code
+
+	if t.Handler != nil {
+		if handler, err = c.compile(t.Handler, false); err != nil {
+			return err
+		}
+	}
+
+	if t.Finalizer != nil {
+		if finalizer, err = c.compile(t.Finalizer, false); err != nil {
+			return err
+		}
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		run := func() (object.Object, error) {
+			obj, err := body(f)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return c.force(obj)
+		}
+
+		var handle func(*object.Error) (object.Object, error)
+
+		if handler != nil {
+			handle = func(raised *object.Error) (object.Object, error) {
+				fn, err := handler(f)
+
+				if err != nil {
+					return nil, err
+				}
+
+				return c.Apply(fn, raised)
+			}
+		}
+
+		var finish func() error
+
+		if finalizer != nil {
+			finish = func() error {
+				_, err := finalizer(f)
+				return err
+			}
+		}
+
+		return evaluator.Try(run, handle, finish)
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitFunction(fl *ast.FunctionLiteral) error {
+	body, err := c.compile(fl.Body, true)
+
+	if err != nil {
+		return err
+	}
+
+	c.bodies[fl.Body] = body
+
+	c.code = func(f *Frame) (object.Object, error) {
+		return &object.Function{
+			Parameters:  fl.Parameters,
+			Defaults:    fl.Defaults,
+			Variadic:    fl.Variadic,
+			Block:       fl.Block,
+			Body:        fl.Body,
+			Environment: f,
+			Locals:      fl.Locals,
+		}, nil
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitRecord(r *ast.RecordLiteral) error {
+	values := []ast.Expression{}
+
+	// the base comes first, so it is evaluated before the fields
+	if r.Base != nil {
+		values = append(values, r.Base)
+	}
+
+	for _, field := range r.Fields {
+		values = append(values, field.Expression)
+	}
+
+	elements, err := c.compileElements(values)
+
+	if err != nil {
+		return err
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		objs, err := elements(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var base object.Object
+
+		if r.Base != nil {
+			base, objs = objs[0], objs[1:]
+		}
+
+		return evaluator.BuildRecord(base, r.Fields, objs)
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitMap(n *ast.MapLiteral) error {
+	keys, err := c.compileElements(n.Keys)
+
+	if err != nil {
+		return err
+	}
+
+	values, err := c.compileElements(n.Values)
+
+	if err != nil {
+		return err
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		ks, err := keys(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		vs, err := values(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		m := object.NewMap()
+
+		for i, k := range ks {
+			if err := m.Set(k, vs[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		return m, nil
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitArray(a *ast.ArrayLiteral) error {
+	elements, err := c.compileElements(a.Elements)
+
+	if err != nil {
+		return err
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		objs, err := elements(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		size := uint64(len(objs))
+
+		if size != a.Size {
+			return nil, fmt.Errorf("expected array of size %d, but got %d", a.Size, size)
+		}
+
+		return &object.Array{
+			Value: objs,
+			Size:  size,
+		}, nil
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitSlice(s *ast.SliceLiteral) error {
+	elements, err := c.compileElements(s.Elements)
+
+	if err != nil {
+		return err
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		objs, err := elements(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return object.NewSlice(objs), nil
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitTuple(t *ast.TupleLiteral) error {
+	elements, err := c.compileElements(t.Elements)
+
+	if err != nil {
+		return err
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		objs, err := elements(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &object.Tuple{Elements: objs}, nil
+	}
+
+	return nil
+}
+
+func (c *Compiler) VisitUnpack(u *ast.Unpack) error {
+	tuple, err := c.compile(u.Tuple, false)
+
+	if err != nil {
+		return err
+	}
+
+	c.code = func(f *Frame) (object.Object, error) {
+		obj, err := tuple(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if obj, err = c.force(obj); err != nil {
+			return nil, err
+		}
+
+		return evaluator.Unpack(obj, u.Index, u.Size)
+	}
+
+	return nil
+}
+
+// Literals evaluate to the same immutable object every time they are run.
+func (c *Compiler) constant(obj object.Object) {
+	c.code = func(_ *Frame) (object.Object, error) {
+		return obj, nil
+	}
+}
+
+func (c *Compiler) VisitInteger(n *ast.IntegerLiteral) error {
+	c.constant(&object.Integer{
+		Value: int64(*n),
+	})
+
+	return nil
+}
+
+func (c *Compiler) VisitBigInteger(n *ast.BigIntegerLiteral) error {
+	c.constant(&object.BigInt{
+		Value: n.Value,
+	})
+
+	return nil
+}
+
+func (c *Compiler) VisitFloat(n *ast.FloatLiteral) error {
+	c.constant(&object.Float{
+		Value: float64(*n),
+	})
+
+	return nil
+}
+
+func (c *Compiler) VisitString(s *ast.StringLiteral) error {
+	c.constant(&object.String{
+		Value: string(*s),
+	})
+
+	return nil
+}
+
+func (c *Compiler) VisitCharacter(ch *ast.CharacterLiteral) error {
+	c.constant(&object.Character{
+		Value: string(*ch),
+	})
+
+	return nil
+}
+
+func (c *Compiler) VisitBoolean(b *ast.BooleanLiteral) error {
+	value, err := strconv.ParseBool(string(*b))
+
+	if err != nil {
+		return err
+	}
+
+	c.constant(object.BoxBoolean(value))
+
+	return nil
+}
+
+/*** Runtime Methods ***/
+
+// Applies the function and forces the result, since
+// the caller is a builtin that is going to consume it.
+func (c *Compiler) Apply(fn object.Object, args ...object.Object) (object.Object, error) {
+	return evaluator.ApplyForced(applier{c}, fn, args...)
+}
+
+func (c *Compiler) Stdout() io.Writer {
+	return c.config.Stdout
+}
+
+func (c *Compiler) Stderr() io.Writer {
+	return c.config.Stderr
+}
+
+// Applies functions for the compiler, like the evaluator's applier.
+type applier struct {
+	*Compiler
+}
+
+func (a applier) Run(fn *object.Function, f *Frame) (object.Object, error) {
+	body, err := a.body(fn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return body(f)
+}
+
+func (a applier) Default(expr ast.Expression, f *Frame) (object.Object, error) {
+	run, err := a.compileOnce(expr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.delayed(expr, run)(f)
+}
+
+func (a applier) Force(obj object.Object) (object.Object, error) {
+	return a.force(obj)
+}
+
+func (a applier) Meter() *evaluator.Meter {
+	return &a.meter
+}
+
+func (c *Compiler) apply(fn object.Object, args ...object.Object) (object.Object, error) {
+	return evaluator.Apply(applier{c}, fn, args...)
+}
+
+func (c *Compiler) applyNamed(fn object.Object, args []object.Object, named []evaluator.NamedArgument) (object.Object, error) {
+	return evaluator.ApplyNamed(applier{c}, fn, args, named)
+}
+
+func (c *Compiler) applyFunction(fn *object.Function, args []object.Object, named []evaluator.NamedArgument) (object.Object, error) {
+	return evaluator.ApplyFunction(applier{c}, fn, args, named)
+}
+
+// Returns the compiled body of the function, compiling it first
+// if the function was created by another backend.
+func (c *Compiler) body(fn *object.Function) (code, error) {
+	if body, ok := c.bodies[fn.Body]; ok {
+		return body, nil
+	}
+
+	body, err := c.compile(fn.Body, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.bodies[fn.Body] = body
+
+	return body, nil
+}
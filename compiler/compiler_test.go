@@ -0,0 +1,260 @@
+package compiler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"raiton/evaluator"
+	"raiton/lexer"
+	"raiton/object"
+	"raiton/parser"
+)
+
+func testCompilation(env *object.Environment, input string, opts ...evaluator.Option) (object.Object, error) {
+	l := lexer.New(input)
+	p := parser.New(&l)
+	program, err := p.Parse()
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := New(env, opts...)
+
+	return c.Evaluate(program)
+}
+
+func testCompilationWith(input string, opts ...evaluator.Option) (object.Object, error) {
+	return testCompilation(object.NewEnvironment(), input, opts...)
+}
+
+// Runs the input with both backends and checks that they agree.
+func TestCompilationAgreesWithEvaluation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5", "5"},
+		{"true", "true"},
+		{"[3: 1 2 3]", "[3: 1 2 3]"},
+		{"r: { a: (add 1 1) } s: [r.a 3] s.1", "3"},
+		{"if false { 1 } else if (eq 0 0) { 2 } else { 3 }", "2"},
+		{"x: if false { 1 } else { y: 5 (add y 1) } x", "6"},
+		{"fn adder n -> \\x -> (add x n) add_two: (adder 2) n: 40 (add_two n)", "42"},
+		{"fn twice f x -> (f (f x)) (twice \\n -> (add n 1) 5)", "7"},
+		{"(map [1 2] \\n -> (add n 1))", "[2: 2 3]"},
+		{"t: lazy (add 20 1) (add (force t) t)", "42"},
+		{"fn f x { y: (add x 1) y: (add y 1) y } (f 1)", "3"},
+		{"x: 10 fn f { y: x x: 5 (add x y) } (f)", "15"},
+		{"fn f { even: \\n -> if (eq n 0) { true } else { (odd (sub n 1)) } odd: \\n -> if (eq n 0) { false } else { (even (sub n 1)) } (even 7) } (f)", "false"},
+		{"fn outer a { fn inner b -> (add a b) (inner 2) } (outer 40)", "42"},
+		{"fn f c { if c { x: 1 } else { 2 } x } x: 7 (f false)", "7"},
+		{"fn f o { for x in [1 2] { o? } (Some 1) } [(f (Some 0)) (f None)]", "[(Some 1) None]"},
+	}
+
+	modes := [][]evaluator.Option{{}, {evaluator.WithDynamicLookup()}}
+
+	for _, tt := range tests {
+		for _, opts := range modes {
+			compiled, err := testCompilationWith(tt.input, opts...)
+
+			if err != nil {
+				t.Fatalf("%s: %s", tt.input, err)
+			}
+
+			l := lexer.New(tt.input)
+			p := parser.New(&l)
+			program, err := p.Parse()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e := evaluator.New(object.NewEnvironment(), opts...)
+			evaluated, err := e.Evaluate(program)
+
+			if err != nil {
+				t.Fatalf("%s: %s", tt.input, err)
+			}
+
+			if compiled.Inspect() != tt.expected || evaluated.Inspect() != tt.expected {
+				t.Errorf("%s: expected %s, but got %s compiled and %s evaluated", tt.input, tt.expected, compiled.Inspect(), evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestCompilationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"nope", "'nope' not defined"},
+		{"if 1 { 1 } else { 2 }", "expected condition to be boolean, but got integer"},
+		{"fn f a b -> a (f 1 2 3)", "function expects 2 arguments, but got 3"},
+		{"[2: 1]", "expected array of size 2, but got 1"},
+		{"r: { a: 1 } r.b", "field 'b' not defined on record"},
+	}
+
+	for _, tt := range tests {
+		_, err := testCompilationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestCompilationLimits(t *testing.T) {
+	forever := `
+	fn forever x -> (add 1 (forever x))
+	(forever 1)
+	`
+
+	sleepy := evaluator.DefaultBuiltins()
+	sleepy["sleep"] = object.MakeBuiltin(func(_ object.Runtime, _ ...object.Object) (object.Object, error) {
+		time.Sleep(20 * time.Millisecond)
+		return object.TRUE, nil
+	})
+
+	tests := []struct {
+		name     string
+		input    string
+		opts     []evaluator.Option
+		expected error
+	}{
+		{"steps", forever, []evaluator.Option{evaluator.WithMaxSteps(100)}, evaluator.ErrStepLimit},
+		{"depth", forever, []evaluator.Option{evaluator.WithMaxDepth(100)}, evaluator.ErrDepthLimit},
+		{"timeout", `(sleep) (sleep)`, []evaluator.Option{evaluator.WithBuiltins(sleepy), evaluator.WithTimeout(10 * time.Millisecond)}, evaluator.ErrTimeout},
+		{"callback steps", `(fold (range 1000) 0 add)`, []evaluator.Option{evaluator.WithMaxSteps(100)}, evaluator.ErrStepLimit},
+		{"callback timeout", `(map [1 2 3] sleep)`, []evaluator.Option{evaluator.WithBuiltins(sleepy), evaluator.WithTimeout(10 * time.Millisecond)}, evaluator.ErrTimeout},
+	}
+
+	for _, tt := range tests {
+		_, err := testCompilationWith(tt.input, tt.opts...)
+
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected error %q, but got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestCompilationCanceled(t *testing.T) {
+	l := lexer.New(`(add 1 2)`)
+	p := parser.New(&l)
+	program, err := p.Parse()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := New(object.NewEnvironment())
+
+	if _, err := c.EvaluateContext(ctx, program); !errors.Is(err, evaluator.ErrCanceled) {
+		t.Fatalf("expected error %q, but got %v", evaluator.ErrCanceled, err)
+	}
+}
+
+func TestCompilationTailCalls(t *testing.T) {
+	// deep enough to overflow the Go stack if tail calls were nested
+	iterations := int64(1_000_000)
+
+	if testing.Short() {
+		iterations = 100_000
+	}
+
+	input := fmt.Sprintf(`
+	fn count n acc {
+		if (eq n 0) { acc } else { (count (sub n 1) (add acc 1)) }
+	}
+
+	(count %d 0)
+	`, iterations)
+
+	compiled, err := testCompilationWith(input, evaluator.WithMaxDepth(100))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if compiled.Inspect() != fmt.Sprint(iterations) {
+		t.Fatalf("expected %d, but got %s", iterations, compiled.Inspect())
+	}
+}
+
+func TestCompilationSharesEnvironment(t *testing.T) {
+	env := object.NewEnvironment()
+
+	l := lexer.New(`fn inc x -> (add x 1)`)
+	p := parser.New(&l)
+	program, err := p.Parse()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := evaluator.New(env)
+
+	if _, err := e.Evaluate(program); err != nil {
+		t.Fatal(err)
+	}
+
+	compiled, err := testCompilation(env, `(inc (inc 40))`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if compiled.Inspect() != "42" {
+		t.Fatalf("expected 42, but got %s", compiled.Inspect())
+	}
+}
+
+func BenchmarkRecursiveFibonacci(b *testing.B) {
+	input := `
+	fn fib n {
+		if (eq n 0) { 0 } else if (eq n 1) { 1 } else {
+			(add (fib (sub n 1)) (fib (sub n 2)))
+		}
+	}
+	(fib 20)
+	`
+
+	l := lexer.New(input)
+	p := parser.New(&l)
+	program, err := p.Parse()
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	backends := []struct {
+		name string
+		new  func() evaluator.Backend
+	}{
+		{"evaluator", func() evaluator.Backend {
+			e := evaluator.New(object.NewEnvironment())
+			return &e
+		}},
+		{"compiler", func() evaluator.Backend {
+			c := New(object.NewEnvironment())
+			return &c
+		}},
+	}
+
+	for _, backend := range backends {
+		b.Run(backend.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := backend.new().Evaluate(program); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package compiler
+
+import (
+	"fmt"
+
+	"raiton/ast"
+	"raiton/evaluator"
+	"raiton/object"
+)
+
+// Compiles code which defers the evaluation of the expression
+// in the frame it is run in.
+func (c *Compiler) delay(expr ast.Expression) code {
+	return func(f *Frame) (object.Object, error) {
+		return &object.Thunk{
+			Expression:  expr,
+			Environment: f,
+		}, nil
+	}
+}
+
+// Returns the code of the expression, deferring its evaluation in lazy mode.
+// Literals are evaluated right away, since doing so has no effects.
+func (c *Compiler) delayed(expr ast.Expression, run code) code {
+	if c.config.Lazy && !evaluator.IsLiteral(expr) {
+		return c.delay(expr)
+	}
+
+	return run
+}
+
+func (c *Compiler) compileDelayed(expr ast.Expression) (code, error) {
+	run, err := c.compile(expr, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.delayed(expr, run), nil
+}
+
+// Returns the value of the object, running the code of its expression first
+// if it is a thunk that hasn't been forced yet. Forcing a thunk counts as a
+// function call towards the call depth limit. A thunk may evaluate to another
+// thunk, which is forced in turn in a loop, so chains of them don't grow the
+// stack.
+func (c *Compiler) force(obj object.Object) (object.Object, error) {
+	if _, ok := obj.(*object.Thunk); !ok {
+		return obj, nil
+	}
+
+	var chain []*object.Thunk
+
+	defer func() {
+		for _, thunk := range chain {
+			thunk.Forcing = false
+		}
+	}()
+
+	for {
+		thunk, ok := obj.(*object.Thunk)
+
+		if !ok {
+			break
+		}
+
+		if thunk.Value != nil {
+			obj = thunk.Value
+			break
+		}
+
+		if thunk.Forcing {
+			return nil, fmt.Errorf("lazy value `%s` depends on itself", ast.NewPrinter(thunk.Expression).String())
+		}
+
+		thunk.Forcing = true
+		chain = append(chain, thunk)
+
+		value, err := c.runThunk(thunk)
+
+		if err != nil {
+			return nil, err
+		}
+
+		obj = value
+	}
+
+	for _, thunk := range chain {
+		thunk.Value = obj
+		// the frame is not needed anymore, so don't keep it alive
+		thunk.Expression = nil
+		thunk.Environment = nil
+	}
+
+	return obj, nil
+}
+
+// Runs the code of the thunk's expression in its frame, without forcing
+// the result.
+func (c *Compiler) runThunk(thunk *object.Thunk) (object.Object, error) {
+	run, err := c.compileOnce(thunk.Expression)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.meter.Enter(); err != nil {
+		return nil, err
+	}
+
+	defer c.meter.Leave()
+
+	return run(thunk.Environment)
+}
+
+// Returns the code of an expression which is run apart from the code
+// it is a part of, compiling it the first time it is run.
+func (c *Compiler) compileOnce(expr ast.Expression) (code, error) {
+	if run, ok := c.thunks[expr]; ok {
+		return run, nil
+	}
+
+	run, err := c.compile(expr, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.thunks[expr] = run
+
+	return run, nil
+}
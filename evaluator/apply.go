@@ -0,0 +1,228 @@
+package evaluator
+
+import (
+	"raiton/ast"
+	"raiton/object"
+)
+
+// The parts of applying functions which differ between backends: running
+// the body of a function, evaluating the defaults of its parameters and
+// forcing lazy values. The rest, like binding arguments, applying tail
+// calls, partial application and calling builtins, is shared by the
+// functions below. The Applier is the runtime builtins are called with.
+type Applier interface {
+	object.Runtime
+	// Runs the body of the function in tail position in the frame its
+	// parameters are bound in, returning calls in tail position as a
+	// *TailCall.
+	Run(fn *object.Function, frame *object.Environment) (object.Object, error)
+	// Evaluates the default value of a parameter in the frame.
+	Default(expr ast.Expression, frame *object.Environment) (object.Object, error)
+	Force(obj object.Object) (object.Object, error)
+	Meter() *Meter
+}
+
+// Applies the function and forces the result, since
+// the caller is a builtin that is going to consume it. Each
+// application counts as a step, so that callbacks applied by
+// builtins are limited and canceled like any other call.
+func ApplyForced(a Applier, fn object.Object, args ...object.Object) (object.Object, error) {
+	if err := a.Meter().Step(); err != nil {
+		return nil, err
+	}
+
+	result, err := Apply(a, fn, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Force(result)
+}
+
+// Applies the function to the arguments. Functions applied to fewer
+// arguments than they take are applied partially, and functions applied
+// to more are applied to as many as they take, and their result to the
+// rest.
+func Apply(a Applier, fn object.Object, args ...object.Object) (object.Object, error) {
+	switch fn := fn.(type) {
+	case *object.Function:
+		if len(args) < fn.Required() {
+			return object.NewPartial(fn, args), nil
+		}
+
+		if fn.Takes(len(args)) {
+			return ApplyFunction(a, fn, args, nil)
+		}
+
+		n := fn.Positional()
+		result, err := ApplyFunction(a, fn, args[:n], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if result, err = a.Force(result); err != nil {
+			return nil, err
+		}
+
+		if result.Type() != object.FUNCTION && result.Type() != object.BUILTIN {
+			return nil, ArityError(fn, len(args))
+		}
+
+		return Apply(a, result, args[n:]...)
+	case *object.Partial:
+		return Apply(a, fn.Function, fn.Complete(args)...)
+	case *object.Builtin:
+		if len(args) < fn.Arity {
+			return object.NewPartial(fn, args), nil
+		}
+
+		forced := make([]object.Object, len(args))
+
+		for i, arg := range args {
+			obj, err := a.Force(arg)
+
+			if err != nil {
+				return nil, err
+			}
+
+			forced[i] = obj
+		}
+
+		return fn.Fn(a, forced...)
+	default:
+		return nil, Errorf(KindType, "expected a function, but got %s", fn.Type())
+	}
+}
+
+// Applies the function to positional and named arguments. Applications
+// with named arguments are never partial, so the function has to be given
+// all the arguments it needs.
+func ApplyNamed(a Applier, fn object.Object, args []object.Object, named []NamedArgument) (object.Object, error) {
+	switch fn := fn.(type) {
+	case *object.Function:
+		return ApplyFunction(a, fn, args, named)
+	case *object.Partial:
+		return ApplyNamed(a, fn.Function, fn.Complete(args), named)
+	case *object.Builtin:
+		return nil, Errorf(KindArgument, "builtin functions don't take named arguments")
+	default:
+		return nil, Errorf(KindType, "expected a function, but got %s", fn.Type())
+	}
+}
+
+// Applies the function to as many arguments as it takes, or to named ones,
+// in a new frame enclosed by the one the function was defined in. The body
+// is run in tail position, so calls in tail position are returned as tail
+// calls and applied here in a loop instead of nesting deeper into the Go
+// stack. A ? returns from the call early, with the Err or None it was given,
+// unless the function is a block, which belongs to the call around it.
+func ApplyFunction(a Applier, fn *object.Function, args []object.Object, named []NamedArgument) (object.Object, error) {
+	meter := a.Meter()
+
+	if err := meter.Enter(); err != nil {
+		return nil, err
+	}
+
+	defer meter.Leave()
+
+	// the call a ? returns from, which tail calls share
+	var call *object.Call
+
+	for {
+		if len(named) == 0 && !fn.Takes(len(args)) {
+			// partial applications and applications to more arguments
+			// than the function takes are not tail calls
+			return Apply(a, fn, args...)
+		}
+
+		values, err := BindArguments(fn, args, named)
+
+		if err != nil {
+			return nil, err
+		}
+
+		owner := fn.Environment.Call()
+
+		if !fn.Block {
+			if call == nil {
+				call = &object.Call{Function: fn}
+			}
+
+			owner = call
+		}
+
+		// a ? in a default returns from the function as well
+		frame, err := Bind(a, fn, values, owner)
+
+		var result object.Object
+
+		if err == nil {
+			result, err = a.Run(fn, frame)
+		}
+
+		if err != nil {
+			if value, ok := Returned(call, err); ok {
+				return value, nil
+			}
+
+			return nil, err
+		}
+
+		tail, ok := result.(*TailCall)
+
+		if !ok {
+			return result, nil
+		}
+
+		fn, args, named = tail.Function, tail.Arguments, nil
+	}
+}
+
+// Binds the parameters of the function to their values in a new frame of
+// the call. Parameters without a value are bound to their defaults, which
+// are evaluated in the frame in order, so they can refer to the parameters
+// before them.
+func Bind(a Applier, fn *object.Function, values []object.Object, call *object.Call) (*object.Environment, error) {
+	var frame *object.Environment
+
+	set := func(i int, value object.Object) {
+		frame.Define(string(*fn.Parameters[i]), value)
+	}
+
+	if fn.Locals != nil {
+		// parameters take the first slots of the frame
+		frame = object.NewFrame(fn.Environment, fn.Locals)
+
+		set = func(i int, value object.Object) {
+			frame.Set(i, value)
+		}
+	} else {
+		frame = object.NewEnclosedEnvironment(fn.Environment)
+	}
+
+	frame.SetCall(call)
+
+	for i, value := range values {
+		if value != nil {
+			set(i, value)
+		}
+	}
+
+	for i, value := range values {
+		if value != nil {
+			continue
+		}
+
+		value, err := a.Default(fn.Defaults[i], frame)
+
+		if err != nil {
+			return nil, err
+		}
+
+		set(i, value)
+	}
+
+	return frame, nil
+}
@@ -0,0 +1,87 @@
+package evaluator
+
+import "raiton/object"
+
+// An argument passed by the name of the parameter it binds.
+type NamedArgument struct {
+	Name  string
+	Value object.Object
+}
+
+// Binds the arguments of an application to the parameters of the function.
+// Positional arguments bind the parameters in order, named ones bind the
+// parameters with their names, and the positional arguments left over are
+// bound to the rest parameter as a slice. The values are returned in the
+// order of the parameters, with nil for the ones which are left to their
+// defaults, which are evaluated in the function's frame.
+func BindArguments(fn *object.Function, args []object.Object, named []NamedArgument) ([]object.Object, error) {
+	positional := fn.Positional()
+
+	if len(args) > positional && !fn.Variadic {
+		return nil, ArityError(fn, len(args))
+	}
+
+	values := make([]object.Object, len(fn.Parameters))
+
+	for i := 0; i < positional && i < len(args); i++ {
+		values[i] = args[i]
+	}
+
+	if fn.Variadic {
+		rest := []object.Object{}
+
+		if len(args) > positional {
+			rest = append(rest, args[positional:]...)
+		}
+
+		values[positional] = object.NewSlice(rest)
+	}
+
+	for _, arg := range named {
+		i := parameterIndex(fn, arg.Name)
+
+		switch {
+		case i < 0:
+			return nil, Errorf(KindArgument, "function has no parameter named '%s'", arg.Name)
+		case i == positional:
+			return nil, Errorf(KindArgument, "cannot bind the rest parameter '%s' by name", arg.Name)
+		case values[i] != nil:
+			return nil, Errorf(KindArgument, "parameter '%s' is bound more than once", arg.Name)
+		}
+
+		values[i] = arg.Value
+	}
+
+	for i := 0; i < positional; i++ {
+		if values[i] == nil && (fn.Defaults == nil || fn.Defaults[i] == nil) {
+			return nil, Errorf(KindArgument, "missing argument for parameter '%s'", *fn.Parameters[i])
+		}
+	}
+
+	return values, nil
+}
+
+func parameterIndex(fn *object.Function, name string) int {
+	for i, p := range fn.Parameters {
+		if string(*p) == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Returns the error of applying the function to a number of positional
+// arguments it doesn't take.
+func ArityError(fn *object.Function, got int) error {
+	required, positional := fn.Required(), fn.Positional()
+
+	switch {
+	case fn.Variadic:
+		return Errorf(KindArgument, "function expects at least %d arguments, but got %d", required, got)
+	case required < positional:
+		return Errorf(KindArgument, "function expects %d to %d arguments, but got %d", required, positional, got)
+	default:
+		return Errorf(KindArgument, "function expects %d arguments, but got %d", positional, got)
+	}
+}
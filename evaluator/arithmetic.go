@@ -0,0 +1,291 @@
+package evaluator
+
+import (
+	"cmp"
+	"math"
+	"math/big"
+
+	"raiton/object"
+)
+
+// Arithmetic follows the same promotion rule in every builtin: operations
+// on integers result in integers, and as soon as one of the operands is a
+// float, the operation is done on floats. Integer results which overflow an
+// int64 are computed again on big integers, and big integer results which
+// fit an int64 become regular integers again. Variadic builtins fold their
+// arguments from the left, so `(sub 10 2 3)` is `(sub (sub 10 2) 3)`.
+type arithmetic struct {
+	// reports false if the result overflowed
+	integer func(a, b int64) (int64, bool)
+	big     func(z, a, b *big.Int) *big.Int
+	float   func(a, b float64) float64
+	// whether a zero second operand is a division by zero
+	divides bool
+}
+
+var errDivisionByZero = Errorf(KindArithmetic, "division by zero")
+
+var (
+	addition = arithmetic{
+		integer: func(a, b int64) (int64, bool) {
+			c := a + b
+			return c, (c > a) == (b > 0)
+		},
+		big:   (*big.Int).Add,
+		float: func(a, b float64) float64 { return a + b },
+	}
+	subtraction = arithmetic{
+		integer: func(a, b int64) (int64, bool) {
+			c := a - b
+			return c, (c < a) == (b > 0)
+		},
+		big:   (*big.Int).Sub,
+		float: func(a, b float64) float64 { return a - b },
+	}
+	multiplication = arithmetic{
+		integer: func(a, b int64) (int64, bool) {
+			if a == 0 || b == 0 {
+				return 0, true
+			}
+
+			c := a * b
+			return c, c/b == a && !(a == math.MinInt64 && b == -1)
+		},
+		big:   (*big.Int).Mul,
+		float: func(a, b float64) float64 { return a * b },
+	}
+	// division of integers truncates towards zero
+	division = arithmetic{
+		integer: func(a, b int64) (int64, bool) {
+			return a / b, !(a == math.MinInt64 && b == -1)
+		},
+		big:     (*big.Int).Quo,
+		float:   func(a, b float64) float64 { return a / b },
+		divides: true,
+	}
+	// the remainder has the sign of the dividend
+	modulo = arithmetic{
+		integer: func(a, b int64) (int64, bool) { return a % b, true },
+		big:     (*big.Int).Rem,
+		float:   math.Mod,
+		divides: true,
+	}
+)
+
+func isNumber(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Integer, *object.BigInt, *object.Float:
+		return true
+	default:
+		return false
+	}
+}
+
+func isInteger(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Integer, *object.BigInt:
+		return true
+	default:
+		return false
+	}
+}
+
+func isZero(obj object.Object) bool {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj.Value == 0
+	case *object.BigInt:
+		return obj.Value.Sign() == 0
+	case *object.Float:
+		return obj.Value == 0
+	default:
+		return false
+	}
+}
+
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInt:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f
+	case *object.Float:
+		return obj.Value
+	default:
+		return math.NaN()
+	}
+}
+
+// Returns the value of an integer as a big integer.
+func toBig(obj object.Object) *big.Int {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value)
+	case *object.BigInt:
+		return obj.Value
+	default:
+		return nil
+	}
+}
+
+// Checks that all arguments are numbers, and that there are at least min of them.
+func expectNumbers(args []object.Object, min int) error {
+	if len(args) < min {
+		return Errorf(KindArgument, "expected at least %d arguments, but got %d", min, len(args))
+	}
+
+	for i, arg := range args {
+		if !isNumber(arg) {
+			return Errorf(KindType, "expected argument %d to be a number, but got %s", i+1, arg.Type())
+		}
+	}
+
+	return nil
+}
+
+func (op arithmetic) apply(a object.Object, b object.Object) (object.Object, error) {
+	if op.divides && isZero(b) {
+		return nil, errDivisionByZero
+	}
+
+	if !isInteger(a) || !isInteger(b) {
+		return &object.Float{Value: op.float(toFloat(a), toFloat(b))}, nil
+	}
+
+	x, xok := a.(*object.Integer)
+	y, yok := b.(*object.Integer)
+
+	if xok && yok {
+		if c, ok := op.integer(x.Value, y.Value); ok {
+			return &object.Integer{Value: c}, nil
+		}
+	}
+
+	return object.MakeInteger(op.big(new(big.Int), toBig(a), toBig(b))), nil
+}
+
+// Returns a builtin which folds at least min arguments with the operation.
+func (op arithmetic) builtin(min int) object.BuiltinFunction {
+	return func(_ object.Runtime, args ...object.Object) (object.Object, error) {
+		if err := expectNumbers(args, min); err != nil {
+			return nil, err
+		}
+
+		result := args[0]
+
+		for _, arg := range args[1:] {
+			var err error
+
+			if result, err = op.apply(result, arg); err != nil {
+				return nil, err
+			}
+		}
+
+		return result, nil
+	}
+}
+
+// Returns the quotient and the remainder of the division, as a tuple.
+func divmod(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
+	}
+
+	if err := expectNumbers(args, 2); err != nil {
+		return nil, err
+	}
+
+	quotient, err := division.apply(args[0], args[1])
+
+	if err != nil {
+		return nil, err
+	}
+
+	remainder, err := modulo.apply(args[0], args[1])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &object.Tuple{Elements: []object.Object{quotient, remainder}}, nil
+}
+
+func neg(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectOneNumber(args); err != nil {
+		return nil, err
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		if arg.Value == math.MinInt64 {
+			return object.MakeInteger(new(big.Int).Neg(toBig(arg))), nil
+		}
+
+		return &object.Integer{Value: -arg.Value}, nil
+	case *object.BigInt:
+		return object.MakeInteger(new(big.Int).Neg(arg.Value)), nil
+	default:
+		return &object.Float{Value: -toFloat(arg)}, nil
+	}
+}
+
+func abs(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectOneNumber(args); err != nil {
+		return nil, err
+	}
+
+	if isInteger(args[0]) {
+		if toBig(args[0]).Sign() < 0 {
+			return neg(rt, args...)
+		}
+
+		return args[0], nil
+	}
+
+	return &object.Float{Value: math.Abs(toFloat(args[0]))}, nil
+}
+
+func expectOneNumber(args []object.Object) error {
+	if len(args) != 1 {
+		return Errorf(KindArgument, "expected one argument, but got %d", len(args))
+	}
+
+	return expectNumbers(args, 1)
+}
+
+// Returns a builtin which picks the argument for which better
+// returns true when compared to all others. Ties go to the first.
+func extremum(better func(order int) bool) object.BuiltinFunction {
+	return func(_ object.Runtime, args ...object.Object) (object.Object, error) {
+		if err := expectNumbers(args, 1); err != nil {
+			return nil, err
+		}
+
+		result := args[0]
+
+		for _, arg := range args[1:] {
+			if better(compareNumbers(arg, result)) {
+				result = arg
+			}
+		}
+
+		return result, nil
+	}
+}
+
+// Returns -1, 0 or 1 if a is less than, equal to or greater than b.
+func compareNumbers(a object.Object, b object.Object) int {
+	x, xok := a.(*object.Integer)
+	y, yok := b.(*object.Integer)
+
+	if xok && yok {
+		return cmp.Compare(x.Value, y.Value)
+	}
+
+	if isInteger(a) && isInteger(b) {
+		return toBig(a).Cmp(toBig(b))
+	}
+
+	return cmp.Compare(toFloat(a), toFloat(b))
+}
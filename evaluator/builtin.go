@@ -1,71 +1,136 @@
 package evaluator
 
 import (
-	"fmt"
-
-	"raiton/ast"
 	"raiton/object"
 )
 
-var builtins = map[string]*object.Builtin{
-	"add": object.MakeBuiltin(add),
-	"map": object.MakeBuiltin(mapfn),
+var defaultBuiltins = map[string]object.Object{
+	"add":    object.MakePureBuiltin(addition.builtin(1)).Curried(2),
+	"sub":    object.MakePureBuiltin(subtraction.builtin(2)).Curried(2),
+	"mul":    object.MakePureBuiltin(multiplication.builtin(1)).Curried(2),
+	"div":    object.MakePureBuiltin(division.builtin(2)).Curried(2),
+	"mod":    object.MakePureBuiltin(modulo.builtin(2)).Curried(2),
+	"divmod": object.MakePureBuiltin(divmod).Curried(2),
+	"neg":    object.MakePureBuiltin(neg),
+	"abs":    object.MakePureBuiltin(abs),
+	"min":    object.MakePureBuiltin(extremum(func(order int) bool { return order < 0 })),
+	"max":    object.MakePureBuiltin(extremum(func(order int) bool { return order > 0 })),
+
+	"eq":  object.MakePureBuiltin(eq).Curried(2),
+	"neq": object.MakePureBuiltin(neq).Curried(2),
+	"lt":  object.MakePureBuiltin(ordering(func(order int) bool { return order < 0 })).Curried(2),
+	"gt":  object.MakePureBuiltin(ordering(func(order int) bool { return order > 0 })).Curried(2),
+	"le":  object.MakePureBuiltin(ordering(func(order int) bool { return order <= 0 })).Curried(2),
+	"ge":  object.MakePureBuiltin(ordering(func(order int) bool { return order >= 0 })).Curried(2),
+	"not": object.MakePureBuiltin(not),
+	"and": object.MakePureBuiltin(and),
+	"or":  object.MakePureBuiltin(or),
+
+	"concat":  object.MakePureBuiltin(concat),
+	"print":   object.MakeBuiltin(printfn),
+	"println": object.MakeBuiltin(printlnfn),
+	"str":     namespace(stringFunctions),
+
+	// views are not pure, since they share their elements and
+	// have a capacity which their literals would not preserve
+	"slice":  object.MakeBuiltin(slice),
+	"append": object.MakeBuiltin(appendfn),
+	"len":    object.MakePureBuiltin(length),
+	"cap":    object.MakePureBuiltin(capacity),
+
+	// get is not curried, since it also reads references
+	"get":    object.MakePureBuiltin(get),
+	"put":    object.MakePureBuiltin(put).Curried(3),
+	"delete": object.MakePureBuiltin(deletefn).Curried(2),
+	"has":    object.MakePureBuiltin(has).Curried(2),
+	"keys":   object.MakePureBuiltin(entries(func(entry *object.MapEntry) object.Object { return entry.Key })),
+	"values": object.MakePureBuiltin(entries(func(entry *object.MapEntry) object.Object { return entry.Value })),
+
+	"raise": object.MakeBuiltin(raise),
+
+	"ref":  object.MakeBuiltin(ref),
+	"set!": object.MakeBuiltin(set),
+
+	"Ok":         object.MakePureBuiltin(variant(func(value object.Object) object.Object { return object.Ok(value) })),
+	"Err":        object.MakePureBuiltin(variant(func(value object.Object) object.Object { return object.Err(value) })),
+	"Some":       object.MakePureBuiltin(variant(func(value object.Object) object.Object { return object.Some(value) })),
+	"None":       object.NONE,
+	"and_then":   object.MakePureBuiltin(andThen).Curried(2),
+	"unwrap_or":  object.MakePureBuiltin(unwrapOr).Curried(2),
+	"unwrap":     object.MakePureBuiltin(unwrap),
+	"unwrap_err": object.MakePureBuiltin(unwrapErr),
+	"is_ok":      object.MakePureBuiltin(isVariant(object.RESULT)),
+	"is_some":    object.MakePureBuiltin(isVariant(object.OPTION)),
+
+	"merge":        object.MakePureBuiltin(merge),
+	"fields":       object.MakePureBuiltin(fields),
+	"has_field":    object.MakePureBuiltin(hasField).Curried(2),
+	"remove_field": object.MakePureBuiltin(removeField).Curried(2),
+	"to_pairs":     object.MakePureBuiltin(toPairs),
+	"from_pairs":   object.MakePureBuiltin(fromPairs),
+
+	"map":       object.MakePureBuiltin(mapfn).Curried(2),
+	"filter":    object.MakePureBuiltin(filter).Curried(2),
+	"fold":      object.MakePureBuiltin(fold).Curried(3),
+	"reduce":    object.MakePureBuiltin(reduce).Curried(2),
+	"each":      object.MakeBuiltin(each).Curried(2),
+	"zip":       object.MakePureBuiltin(zip),
+	"enumerate": object.MakePureBuiltin(enumerate),
+	"range":     object.MakePureBuiltin(rangefn),
+	"take":      object.MakePureBuiltin(take).Curried(2),
+	"drop":      object.MakePureBuiltin(drop).Curried(2),
+	"any":       object.MakePureBuiltin(quantifier(true)).Curried(2),
+	"all":       object.MakePureBuiltin(quantifier(false)).Curried(2),
+	"find":      object.MakePureBuiltin(find).Curried(2),
+	"flat_map":  object.MakePureBuiltin(flatMap).Curried(2),
+	"group_by":  object.MakePureBuiltin(groupBy).Curried(2),
+	"sort":      object.MakePureBuiltin(sortfn),
+
+	"compose": object.MakePureBuiltin(compose),
+	"force":   object.MakePureBuiltin(force),
 }
 
-func add(_ ast.Visitor, args ...object.Object) (object.Object, error) {
-	if len(args) != 2 {
-		return nil, fmt.Errorf("expected two integers")
-	}
+// Returns a fresh copy of the default builtin set, which can be
+// extended without affecting other evaluators. Namespaces are
+// copied too, so functions can be added to them as well.
+func DefaultBuiltins() map[string]object.Object {
+	builtins := make(map[string]object.Object, len(defaultBuiltins))
 
-	first, ok := args[0].(*object.Integer)
-	if !ok {
-		return nil, fmt.Errorf("expected first argument to be integer, but got %s", args[0].Type())
-	}
+	for name, obj := range defaultBuiltins {
+		if record, ok := obj.(*object.Record); ok {
+			obj = record.Copy()
+		}
 
-	second, ok := args[1].(*object.Integer)
-	if !ok {
-		return nil, fmt.Errorf("expected second argument to be integer, but got %s", args[1].Type())
+		builtins[name] = obj
 	}
 
-	result := first.Value + second.Value
-
-	return &object.Integer{
-		Value: result,
-	}, nil
+	return builtins
 }
 
-func mapfn(v ast.Visitor, args ...object.Object) (object.Object, error) {
-	if len(args) != 2 {
-		return nil, fmt.Errorf("expected array and mapping function")
-	}
-
-	arr, ok := args[0].(*object.Array)
-
-	if !ok {
-		if slc, ok := args[0].(*object.Slice); ok {
-			arr = slc.Value
-		} else {
-			return nil, fmt.Errorf("expected first argument to be an array, but got %s", args[0].Type())
-		}
+// Applies the function to each element, and returns an array of the results.
+// Applied to an Ok or a Some, it maps the value inside it instead.
+func mapfn(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
 	}
 
-	fn, ok := args[1].(*object.Function)
-	if !ok {
-		return nil, fmt.Errorf("expected second argument to be a function, but got %s", args[1].Type())
+	switch args[0].(type) {
+	case *object.Result, *object.Option:
+		return mapVariant(rt, args...)
 	}
 
-	eval, ok := v.(*Evaluator)
+	it, fn, err := expectIterableAndFunction(args)
 
-	if !ok {
-		return nil, fmt.Errorf("expected Evaluator visitor")
+	if err != nil {
+		return nil, err
 	}
 
 	newArray := &object.Array{
 		Value: []object.Object{},
 	}
 
-	for _, arg := range arr.Value {
-		obj, err := eval.applyFunction(fn, arg)
+	for arg, ok := it.Next(); ok; arg, ok = it.Next() {
+		obj, err := rt.Apply(fn, arg)
 		if err != nil {
 			return nil, err
 		}
@@ -77,3 +142,56 @@ func mapfn(v ast.Visitor, args ...object.Object) (object.Object, error) {
 
 	return newArray, nil
 }
+
+// Builtins are given forced arguments, so forcing is
+// just a matter of passing the argument to a builtin.
+func force(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	return args[0], nil
+}
+
+// Composes the functions like `compose` does, for `f >> g`, which
+// does not depend on what the name `compose` is bound to.
+func Compose(fns ...object.Object) (object.Object, error) {
+	return compose(nil, fns...)
+}
+
+// Returns a function which applies the first function to its arguments,
+// and each of the others to the result of the one before it. A single
+// function is returned as it is.
+func compose(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) == 0 {
+		return nil, Errorf(KindArgument, "expected at least 1 argument, but got 0")
+	}
+
+	for i := range args {
+		if _, err := expectFunction(args, i); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) == 1 {
+		return args[0], nil
+	}
+
+	fns := args
+
+	return object.MakePureBuiltin(func(rt object.Runtime, args ...object.Object) (object.Object, error) {
+		result, err := rt.Apply(fns[0], args...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, fn := range fns[1:] {
+			if result, err = rt.Apply(fn, result); err != nil {
+				return nil, err
+			}
+		}
+
+		return result, nil
+	}), nil
+}
@@ -0,0 +1,130 @@
+package evaluator
+
+import (
+	"cmp"
+
+	"raiton/object"
+)
+
+// Returns a builtin which checks that each argument is in the
+// given order to the next one, like `(lt 1 2 3)`.
+func ordering(ordered func(order int) bool) object.BuiltinFunction {
+	return func(_ object.Runtime, args ...object.Object) (object.Object, error) {
+		if len(args) < 2 {
+			return nil, Errorf(KindArgument, "expected at least 2 arguments, but got %d", len(args))
+		}
+
+		result := true
+
+		for i := range args[1:] {
+			order, err := compareObjects(args[i], args[i+1])
+
+			if err != nil {
+				return nil, err
+			}
+
+			result = result && ordered(order)
+		}
+
+		return object.BoxBoolean(result), nil
+	}
+}
+
+// Compares numbers, strings or characters with each other.
+func compareObjects(a object.Object, b object.Object) (int, error) {
+	if isNumber(a) && isNumber(b) {
+		return compareNumbers(a, b), nil
+	}
+
+	switch a := a.(type) {
+	case *object.String:
+		if b, ok := b.(*object.String); ok {
+			return cmp.Compare(a.Value, b.Value), nil
+		}
+	case *object.Character:
+		if b, ok := b.(*object.Character); ok {
+			return cmp.Compare(a.Value, b.Value), nil
+		}
+	}
+
+	return 0, Errorf(KindType, "cannot compare %s with %s", a.Type(), b.Type())
+}
+
+func eq(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) < 2 {
+		return nil, Errorf(KindArgument, "expected at least 2 arguments, but got %d", len(args))
+	}
+
+	for _, arg := range args[1:] {
+		if !object.Equal(args[0], arg) {
+			return object.FALSE, nil
+		}
+	}
+
+	return object.TRUE, nil
+}
+
+func neq(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) != 2 {
+		return nil, Errorf(KindArgument, "expected 2 arguments, but got %d", len(args))
+	}
+
+	return object.BoxBoolean(!object.Equal(args[0], args[1])), nil
+}
+
+// Checks that all arguments are booleans, and that there are at least min of them.
+func expectBooleans(args []object.Object, min int) error {
+	if len(args) < min {
+		return Errorf(KindArgument, "expected at least %d arguments, but got %d", min, len(args))
+	}
+
+	for i, arg := range args {
+		if _, ok := arg.(*object.Boolean); !ok {
+			return Errorf(KindType, "expected argument %d to be a boolean, but got %s", i+1, arg.Type())
+		}
+	}
+
+	return nil
+}
+
+func not(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) != 1 {
+		return nil, Errorf(KindArgument, "expected one argument, but got %d", len(args))
+	}
+
+	if err := expectBooleans(args, 1); err != nil {
+		return nil, err
+	}
+
+	return object.BoxBoolean(!args[0].(*object.Boolean).Value), nil
+}
+
+// Like all builtins, `and` and `or` get their arguments evaluated,
+// so they don't short-circuit. Conditionals do.
+func and(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectBooleans(args, 1); err != nil {
+		return nil, err
+	}
+
+	for _, arg := range args {
+		if !arg.(*object.Boolean).Value {
+			return object.FALSE, nil
+		}
+	}
+
+	return object.TRUE, nil
+}
+
+func or(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectBooleans(args, 1); err != nil {
+		return nil, err
+	}
+
+	for _, arg := range args {
+		if arg.(*object.Boolean).Value {
+			return object.TRUE, nil
+		}
+	}
+
+	return object.FALSE, nil
+}
@@ -0,0 +1,150 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"raiton/object"
+)
+
+// Errors reported when evaluation is stopped before it completes.
+// They are wrapped with details, so check for them with errors.Is.
+var (
+	ErrCanceled   = errors.New("evaluation canceled")
+	ErrTimeout    = errors.New("evaluation timed out")
+	ErrStepLimit  = errors.New("step limit exceeded")
+	ErrDepthLimit = errors.New("call depth limit exceeded")
+)
+
+// Signals of break and continue, which the loop around them stops them at.
+// They are errors to anything else they reach, like the thunk of a lazy
+// definition which is forced after the loop is done.
+var (
+	ErrBreak    = errors.New("break outside of a loop")
+	ErrContinue = errors.New("continue outside of a loop")
+)
+
+// The signal of a ?, which returns the value from the call of the function
+// around it. It only reaches the top when the call has returned already,
+// which happens to a ? in a lazy value forced after the call.
+type Return struct {
+	Value object.Object
+	Call  *object.Call
+}
+
+func (r *Return) Error() string {
+	return "cannot return early from a function which has returned already"
+}
+
+// Returns the value inside an Ok or a Some, or a Return of an Err or a
+// None from the call the environment of the ? belongs to.
+func Propagate(obj object.Object, env *object.Environment) (object.Object, error) {
+	switch obj := obj.(type) {
+	case *object.Result:
+		if obj.Ok {
+			return obj.Value, nil
+		}
+	case *object.Option:
+		if obj.Value != nil {
+			return obj.Value, nil
+		}
+	default:
+		return nil, Errorf(KindType, "expected a result or an option to propagate, but got %s", obj.Type())
+	}
+
+	return nil, &Return{Value: obj, Call: env.Call()}
+}
+
+// Returns the value of the Return the error is, if it is one from the call.
+func Returned(call *object.Call, err error) (object.Object, bool) {
+	r, ok := err.(*Return)
+
+	if !ok || call == nil || r.Call != call {
+		return nil, false
+	}
+
+	return r.Value, true
+}
+
+func contextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+
+	return ErrCanceled
+}
+
+func stepLimitError(steps int) error {
+	return fmt.Errorf("%w: evaluation took more than %d steps", ErrStepLimit, steps)
+}
+
+func depthLimitError(depth int) error {
+	return fmt.Errorf("%w: calls are nested more than %d deep", ErrDepthLimit, depth)
+}
+
+// Kinds of the errors which evaluation and builtins raise. Errors which
+// are not of a more specific kind are runtime errors, and errors raised
+// by programs without a kind are of the kind "error".
+const (
+	KindError      = "error"
+	KindRuntime    = "runtime"
+	KindArgument   = "argument"
+	KindType       = "type"
+	KindName       = "name"
+	KindField      = "field"
+	KindKey        = "key"
+	KindIndex      = "index"
+	KindArithmetic = "arithmetic"
+	KindValue      = "value"
+)
+
+// Returns an error of the kind, which programs can catch and tell apart
+// from errors of other kinds.
+func Errorf(kind string, format string, args ...any) error {
+	return object.NewError(kind, fmt.Sprintf(format, args...), nil)
+}
+
+// Returns the error as the error object programs catch, or false if it
+// can't be caught, because it stops the evaluation, a loop or a function.
+func Catch(err error) (*object.Error, bool) {
+	if _, ok := err.(*Return); ok {
+		return nil, false
+	}
+
+	for _, uncaught := range []error{ErrCanceled, ErrTimeout, ErrStepLimit, ErrDepthLimit, ErrBreak, ErrContinue} {
+		if errors.Is(err, uncaught) {
+			return nil, false
+		}
+	}
+
+	var raised *object.Error
+
+	if errors.As(err, &raised) {
+		return raised, true
+	}
+
+	return object.NewError(KindRuntime, err.Error(), nil), true
+}
+
+// Runs the body of a try, and the handler with the error the body raises,
+// if there is a handler and the error can be caught. The finalizer, if
+// there is one, runs last whatever happened before, and an error it raises
+// replaces the result.
+func Try(body func() (object.Object, error), handler func(*object.Error) (object.Object, error), finalizer func() error) (object.Object, error) {
+	result, err := body()
+
+	if err != nil && handler != nil {
+		if raised, ok := Catch(err); ok {
+			result, err = handler(raised)
+		}
+	}
+
+	if finalizer != nil {
+		if err := finalizer(); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, err
+}
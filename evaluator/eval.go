@@ -1,48 +1,119 @@
 package evaluator
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"strconv"
 
 	"raiton/ast"
 	"raiton/object"
+	"raiton/resolver"
 )
 
+// Calls nested deeper than this are likely to overflow the Go stack.
+const DefaultMaxDepth = 10000
+
+// Backend evaluates programs. The Evaluator walks the syntax tree
+// directly, while other backends may translate it into another form
+// first, but all of them agree on the results.
+type Backend interface {
+	Evaluate(node ast.Node) (object.Object, error)
+	EvaluateContext(ctx context.Context, node ast.Node) (object.Object, error)
+}
+
 type Evaluator struct {
-	env     *object.Environment
+	env    *object.Environment
+	config Config
+	meter  Meter
+	// set right before evaluating an expression in tail position
+	tail    bool
 	results stack
 }
 
-func New(env *object.Environment) Evaluator {
+func New(env *object.Environment, opts ...Option) Evaluator {
+	config := NewConfig(opts...)
+
 	return Evaluator{
-		env: env,
+		env:    env,
+		config: config,
+		meter:  NewMeter(config),
 	}
 }
 
 func (e *Evaluator) Evaluate(node ast.Node) (object.Object, error) {
+	return e.EvaluateContext(context.Background(), node)
+}
+
+// Evaluates the node, stopping with ErrCanceled or ErrTimeout
+// if the context is done before the evaluation completes.
+func (e *Evaluator) EvaluateContext(ctx context.Context, node ast.Node) (object.Object, error) {
+	ctx, cancel := e.meter.Start(ctx)
+	defer cancel()
+
+	if !e.config.Dynamic {
+		r := resolver.New()
+
+		if err := r.Resolve(node); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := node.Accept(e); err != nil {
 		return nil, err
 	}
 
-	return e.results.popSafe()
+	result, err := e.results.popSafe()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return e.force(result)
+}
+
+// Evaluates the node, marking it as being in tail position if tail is set.
+// Only nodes which take the mark as the first thing they do are marked,
+// so it can't be mistaken for the mark of a nested node.
+func (e *Evaluator) acceptTail(node ast.Node, tail bool) error {
+	switch node.(type) {
+	case *ast.Scope, *ast.Conditional, *ast.Application:
+		e.tail = tail
+	}
+
+	return node.Accept(e)
+}
+
+func (e *Evaluator) takeTail() bool {
+	tail := e.tail
+	e.tail = false
+	return tail
 }
 
 /*** Visitor Methods ***/
 
 func (e *Evaluator) VisitScope(s *ast.Scope) error {
-	for _, def := range s.Definitions {
-		if err := def.Accept(e); err != nil {
-			return nil
-		}
-	}
+	tail := e.takeTail()
 
 	var returnValue object.Object
 
-	for _, expr := range s.Expressions {
-		if err := expr.Accept(e); err != nil {
+	result := s.Result()
+
+	for i, item := range s.Items {
+		if err := e.meter.Step(); err != nil {
+			return err
+		}
+
+		// the result is only in tail position if nothing is evaluated after it
+		if err := e.acceptTail(item, tail && i == result && i == len(s.Items)-1); err != nil {
 			return err
 		}
-		returnValue = e.results.pop()
+
+		value := e.results.pop()
+
+		if i == result {
+			returnValue = value
+		}
 	}
 
 	// return the evaluation result of the last expression in scope
@@ -56,13 +127,17 @@ func (e *Evaluator) VisitScope(s *ast.Scope) error {
 func (e *Evaluator) VisitDefinition(d *ast.Definition) error {
 	ident := string(d.Identifier)
 
-	if err := d.Expression.Accept(e); err != nil {
+	obj, err := e.evaluateDelayed(d.Expression)
+
+	if err != nil {
 		return err
 	}
 
-	obj := e.results.pop()
-
-	obj = e.env.Define(ident, obj)
+	if d.Binding != nil {
+		obj = e.env.Set(d.Binding.Index, obj)
+	} else {
+		obj = e.env.Define(ident, obj)
+	}
 
 	e.results.push(obj)
 
@@ -73,16 +148,22 @@ func (e *Evaluator) VisitIdentifier(i *ast.Identifier) error {
 	ident := string(*i)
 
 	if obj, ok := e.env.Lookup(ident); ok {
+		obj, err := e.force(obj)
+
+		if err != nil {
+			return err
+		}
+
 		e.results.push(obj)
 		return nil
 	}
 
-	if obj, ok := builtins[ident]; ok {
+	if obj, ok := e.config.Builtins[ident]; ok {
 		e.results.push(obj)
 		return nil
 	}
 
-	return fmt.Errorf("'%s' not defined", ident)
+	return Errorf(KindName, "'%s' not defined", ident)
 }
 
 func (e *Evaluator) VisitSelector(s *ast.Selector) error {
@@ -95,12 +176,26 @@ func (e *Evaluator) VisitSelector(s *ast.Selector) error {
 	var obj object.Object
 	var ok bool
 
-	if obj, ok = e.env.Lookup(ident); !ok {
-		if obj, ok = builtins[ident]; !ok {
-			return fmt.Errorf("'%s' not defined", ident)
+	if s.Binding != nil {
+		obj = e.env.Get(s.Binding.Depth, s.Binding.Index)
+	}
+
+	// a slot is not assigned if its definition has not been evaluated yet,
+	// in which case the name may refer to a binding of an enclosing scope
+	if obj == nil {
+		if obj, ok = e.env.Lookup(ident); !ok {
+			if obj, ok = e.config.Builtins[ident]; !ok {
+				return Errorf(KindName, "'%s' not defined", ident)
+			}
 		}
 	}
 
+	obj, err := e.force(obj)
+
+	if err != nil {
+		return err
+	}
+
 	e.results.push(obj)
 
 	for _, i := range s.Items[1:] {
@@ -114,161 +209,431 @@ func (e *Evaluator) VisitSelector(s *ast.Selector) error {
 
 func (e *Evaluator) VisitSelectorItem(i *ast.SelectorItem) error {
 	obj := e.results.pop()
+	key, err := e.key(i)
 
-	switch obj.Type() {
-	case object.RECORD:
-		record := obj.(*object.Record)
+	if err != nil {
+		return err
+	}
 
-		if i.Identifier == nil {
-			return fmt.Errorf("can only access record fields with identifiers")
+	if i.End != nil {
+		end, err := e.key(i.End)
+
+		if err != nil {
+			return err
 		}
 
-		ident := string(*i.Identifier)
+		if obj, err = SelectRange(obj, key, end); err != nil {
+			return err
+		}
+	} else if obj, err = Select(obj, key); err != nil {
+		return err
+	}
+
+	e.results.push(obj)
+
+	return nil
+}
+
+// Returns the key of the selector item, evaluating it if it is computed.
+func (e *Evaluator) key(i *ast.SelectorItem) (object.Object, error) {
+	if i.Expression == nil {
+		return ItemKey(i), nil
+	}
 
-		obj, ok := record.Value[ident]
+	if err := i.Expression.Accept(e); err != nil {
+		return nil, err
+	}
+
+	return e.popForced()
+}
+
+func (e *Evaluator) VisitApplication(a *ast.Application) error {
+	tail := e.takeTail()
+
+	if len(a.Arguments) < 1 {
+		return fmt.Errorf("expected at least one expression")
+	}
+
+	if err := e.meter.Step(); err != nil {
+		return err
+	}
+
+	if err := a.Arguments[0].Accept(e); err != nil {
+		return err
+	}
+
+	obj, err := e.popForced()
+
+	if err != nil {
+		return err
+	}
 
-		if !ok {
-			return fmt.Errorf("field '%s' not defined on record", ident)
+	switch obj.Type() {
+	case object.FUNCTION, object.BUILTIN:
+		if obj.Type() == object.BUILTIN && len(a.Named) > 0 {
+			return Errorf(KindArgument, "builtin functions don't take named arguments")
 		}
 
-		e.results.push(obj)
+		args := []object.Object{}
 
-		return nil
-	case object.ARRAY:
-		array := obj.(*object.Array)
+		for _, a := range a.Arguments[1:] {
+			var arg object.Object
+			var err error
+
+			// builtins force their arguments, so there is no point in delaying them
+			if obj.Type() == object.FUNCTION {
+				arg, err = e.evaluateDelayed(a)
+			} else if err = a.Accept(e); err == nil {
+				arg = e.results.pop()
+			}
+
+			if err != nil {
+				return err
+			}
 
-		if i.Index == nil {
-			return fmt.Errorf("can only access array elements with index")
+			args = append(args, arg)
 		}
 
-		index := int64(*i.Index)
+		named := []NamedArgument{}
+
+		for _, a := range a.Named {
+			arg, err := e.evaluateDelayed(a.Expression)
+
+			if err != nil {
+				return err
+			}
 
-		if index > int64(len(array.Value)) {
-			return fmt.Errorf("index %d is out of bounds", index)
+			named = append(named, NamedArgument{Name: string(a.Identifier), Value: arg})
 		}
 
-		obj := array.Value[index]
+		if len(named) > 0 {
+			result, err := e.applyNamed(obj, args, named)
 
-		e.results.push(obj)
+			if err != nil {
+				return err
+			}
 
-		return nil
-	case object.SLICE:
-		slice := obj.(*object.Slice)
-		array := slice.Value
+			e.results.push(result)
 
-		if i.Index == nil {
-			return fmt.Errorf("can only access array elements with index")
+			return nil
 		}
 
-		index := int64(*i.Index)
+		if function, ok := obj.(*object.Function); ok && tail {
+			e.results.push(&TailCall{
+				Function:  function,
+				Arguments: args,
+			})
 
-		if index > int64(len(array.Value)) {
-			return fmt.Errorf("index %d is out of bounds", index)
+			return nil
 		}
 
-		obj := array.Value[index]
+		result, err := e.apply(obj, args...)
 
-		e.results.push(obj)
+		if err != nil {
+			return err
+		}
 
-		return nil
+		e.results.push(result)
 	default:
-		return fmt.Errorf("expected a collection but got %s", obj.Type())
+		e.results.push(obj)
 	}
+
+	return nil
 }
 
-func (e *Evaluator) VisitApplication(a *ast.Application) error {
-	if len(a.Arguments) < 1 {
-		return fmt.Errorf("expected at least one expression")
+func (e *Evaluator) VisitConditional(c *ast.Conditional) error {
+	tail := e.takeTail()
+
+	if err := c.Condition.Accept(e); err != nil {
+		return err
 	}
 
-	if err := a.Arguments[0].Accept(e); err != nil {
+	obj, err := e.popForced()
+
+	if err != nil {
 		return err
 	}
 
-	obj := e.results.pop()
+	condition, ok := obj.(*object.Boolean)
 
-	switch obj.Type() {
-	case object.FUNCTION:
-		function := obj.(*object.Function)
+	if !ok {
+		return Errorf(KindType, "expected condition to be boolean, but got %s", obj.Type())
+	}
 
-		args := a.Arguments[1:]
+	if condition.Value {
+		return e.acceptTail(c.Consequence, tail)
+	}
 
-		if len(args) != len(function.Parameters) {
-			return fmt.Errorf("function expects %d arguments, but got %d", len(function.Parameters), len(args))
-		}
+	return e.acceptTail(c.Alternative, tail)
+}
 
-		e.env = object.NewEnclosedEnvironment(e.env)
+func (e *Evaluator) VisitLazy(l *ast.Lazy) error {
+	e.results.push(e.delay(l.Expression))
+	return nil
+}
 
-		for i, p := range function.Parameters {
-			arg := args[i]
+func (e *Evaluator) VisitFor(f *ast.For) error {
+	if err := f.Iterable.Accept(e); err != nil {
+		return err
+	}
 
-			if err := arg.Accept(e); err != nil {
-				return err
-			}
+	collection, err := e.popForced()
 
-			ident := string(*p)
-			obj := e.results.pop()
+	if err != nil {
+		return err
+	}
 
-			e.env.Define(ident, obj)
-		}
+	if err := f.Body.Accept(e); err != nil {
+		return err
+	}
 
-		if err := function.Body.Accept(e); err != nil {
-			return err
-		}
+	body := e.results.pop()
+	height := e.results.height()
 
-		e.env = e.env.Enclosing()
-	case object.BUILTIN:
-		function := obj.(*object.Builtin).Fn
+	result, err := Loop(collection, func(element object.Object) error {
+		// the body is forced, since it is evaluated for its effects
+		_, err := e.Apply(body, element)
+		e.results.truncate(height)
 
-		objs := []object.Object{}
+		return err
+	})
 
-		for _, a := range a.Arguments[1:] {
-			if err := a.Accept(e); err != nil {
-				return err
-			}
+	if err != nil {
+		return err
+	}
+
+	e.results.push(result)
+
+	return nil
+}
+
+func (e *Evaluator) VisitBreak(b *ast.Break) error {
+	return ErrBreak
+}
+
+func (e *Evaluator) VisitContinue(c *ast.Continue) error {
+	return ErrContinue
+}
+
+func (e *Evaluator) VisitPropagate(p *ast.Propagate) error {
+	if err := p.Expression.Accept(e); err != nil {
+		return err
+	}
+
+	obj, err := e.popForced()
+
+	if err != nil {
+		return err
+	}
+
+	value, err := Propagate(obj, e.env)
+
+	if err != nil {
+		return err
+	}
+
+	e.results.push(value)
+
+	return nil
+}
+
+func (e *Evaluator) VisitAssignment(a *ast.Assignment) error {
+	if err := a.Target.Accept(e); err != nil {
+		return err
+	}
 
-			obj := e.results.pop()
-			objs = append(objs, obj)
+	target, err := e.popForced()
+
+	if err != nil {
+		return err
+	}
+
+	if err := a.Value.Accept(e); err != nil {
+		return err
+	}
+
+	value, err := e.popForced()
+
+	if err != nil {
+		return err
+	}
+
+	result, err := Assign(target, value)
+
+	if err != nil {
+		return err
+	}
+
+	e.results.push(result)
+
+	return nil
+}
+
+func (e *Evaluator) VisitComposition(c *ast.Composition) error {
+	fns := []object.Object{}
+
+	for _, expr := range c.Functions {
+		if err := expr.Accept(e); err != nil {
+			return err
 		}
 
-		// TODO: Better error handling
-		obj, err := function(e, objs...)
+		fn, err := e.popForced()
 
 		if err != nil {
 			return err
 		}
+		fns = append(fns, fn)
+	}
 
-		e.results.push(obj)
-	default:
-		e.results.push(obj)
+	composed, err := Compose(fns...)
+
+	if err != nil {
+		return err
 	}
 
+	e.results.push(composed)
+
 	return nil
 }
 
-func (e *Evaluator) applyFunction(fn *object.Function, args ...object.Object) (object.Object, error) {
-	if len(args) != len(fn.Parameters) {
-		return nil, fmt.Errorf("function expects %d arguments, but got %d", len(fn.Parameters), len(args))
+// Evaluates a try. Nothing in it is in tail position, since the calls
+// made in it have to return to it for their errors to be caught.
+func (e *Evaluator) VisitTry(t *ast.Try) error {
+	height := e.results.height()
+
+	body := func() (object.Object, error) {
+		if err := t.Body.Accept(e); err != nil {
+			return nil, err
+		}
+
+		return e.popForced()
 	}
 
-	for i, p := range fn.Parameters {
-		ident := string(*p)
-		e.env.Define(ident, args[i])
+	var handler func(*object.Error) (object.Object, error)
+
+	if t.Handler != nil {
+		handler = func(raised *object.Error) (object.Object, error) {
+			e.results.truncate(height)
+
+			if err := t.Handler.Accept(e); err != nil {
+				return nil, err
+			}
+
+			return e.Apply(e.results.pop(), raised)
+		}
 	}
 
-	if err := fn.Body.Accept(e); err != nil {
+	var finalizer func() error
+
+	if t.Finalizer != nil {
+		finalizer = func() error {
+			e.results.truncate(height)
+
+			if err := t.Finalizer.Accept(e); err != nil {
+				return err
+			}
+
+			// the finalizer is evaluated for its effects only
+			e.results.truncate(height)
+
+			return nil
+		}
+	}
+
+	result, err := Try(body, handler, finalizer)
+
+	if err != nil {
+		return err
+	}
+
+	e.results.push(result)
+
+	return nil
+}
+
+/*** Runtime Methods ***/
+
+// Applies the function and forces the result, since
+// the caller is a builtin that is going to consume it.
+func (e *Evaluator) Apply(fn object.Object, args ...object.Object) (object.Object, error) {
+	return ApplyForced(applier{e}, fn, args...)
+}
+
+func (e *Evaluator) Stdout() io.Writer {
+	return e.config.Stdout
+}
+
+func (e *Evaluator) Stderr() io.Writer {
+	return e.config.Stderr
+}
+
+// Applies functions for the evaluator. It is what builtins are called
+// with, and is as small as a pointer, so it is passed without allocating.
+type applier struct {
+	*Evaluator
+}
+
+// Evaluates the body of the function in the frame, which is the current
+// environment until it returns. The results the body leaves behind when
+// a ? returns from it early are dropped.
+func (a applier) Run(fn *object.Function, frame *object.Environment) (object.Object, error) {
+	enclosing, height := a.env, a.results.height()
+	a.env = frame
+
+	defer func() {
+		a.env = enclosing
+	}()
+
+	if err := a.acceptTail(fn.Body, true); err != nil {
+		a.results.truncate(height)
 		return nil, err
 	}
 
-	obj := e.results.pop()
-	return obj, nil
+	return a.results.popSafe()
+}
+
+func (a applier) Default(expr ast.Expression, frame *object.Environment) (object.Object, error) {
+	enclosing, height := a.env, a.results.height()
+	a.env = frame
+
+	defer func() {
+		a.env = enclosing
+	}()
+
+	value, err := a.evaluateDelayed(expr)
+
+	if err != nil {
+		a.results.truncate(height)
+	}
+
+	return value, err
+}
+
+func (a applier) Force(obj object.Object) (object.Object, error) {
+	return a.force(obj)
+}
+
+func (a applier) Meter() *Meter {
+	return &a.meter
+}
+
+func (e *Evaluator) apply(fn object.Object, args ...object.Object) (object.Object, error) {
+	return Apply(applier{e}, fn, args...)
+}
+
+func (e *Evaluator) applyNamed(fn object.Object, args []object.Object, named []NamedArgument) (object.Object, error) {
+	return ApplyNamed(applier{e}, fn, args, named)
 }
 
 func (e *Evaluator) VisitFunction(f *ast.FunctionLiteral) error {
 	obj := &object.Function{
 		Parameters:  f.Parameters,
+		Defaults:    f.Defaults,
+		Variadic:    f.Variadic,
+		Block:       f.Block,
 		Body:        f.Body,
 		Environment: e.env,
+		Locals:      f.Locals,
 	}
 
 	e.results.push(obj)
@@ -277,17 +642,42 @@ func (e *Evaluator) VisitFunction(f *ast.FunctionLiteral) error {
 }
 
 func (e *Evaluator) VisitRecord(r *ast.RecordLiteral) error {
-	record := &object.Record{
-		Value: map[string]object.Object{},
+	var base object.Object
+
+	if r.Base != nil {
+		if err := r.Base.Accept(e); err != nil {
+			return err
+		}
+
+		obj, err := e.popForced()
+
+		if err != nil {
+			return err
+		}
+
+		base = obj
 	}
 
-	for field, value := range r.Fields {
-		if err := value.Accept(e); err != nil {
+	values := make([]object.Object, len(r.Fields))
+
+	for i, field := range r.Fields {
+		if err := field.Expression.Accept(e); err != nil {
 			return err
 		}
 
-		obj := e.results.pop()
-		record.Value[string(field)] = obj
+		obj, err := e.popForced()
+
+		if err != nil {
+			return err
+		}
+
+		values[i] = obj
+	}
+
+	record, err := BuildRecord(base, r.Fields, values)
+
+	if err != nil {
+		return err
 	}
 
 	e.results.push(record)
@@ -295,6 +685,40 @@ func (e *Evaluator) VisitRecord(r *ast.RecordLiteral) error {
 	return nil
 }
 
+func (e *Evaluator) VisitMap(n *ast.MapLiteral) error {
+	m := object.NewMap()
+
+	for i, key := range n.Keys {
+		if err := key.Accept(e); err != nil {
+			return err
+		}
+
+		k, err := e.popForced()
+
+		if err != nil {
+			return err
+		}
+
+		if err := n.Values[i].Accept(e); err != nil {
+			return err
+		}
+
+		v, err := e.popForced()
+
+		if err != nil {
+			return err
+		}
+
+		if err := m.Set(k, v); err != nil {
+			return err
+		}
+	}
+
+	e.results.push(m)
+
+	return nil
+}
+
 func (e *Evaluator) VisitArray(a *ast.ArrayLiteral) error {
 	objs := []object.Object{}
 
@@ -303,7 +727,11 @@ func (e *Evaluator) VisitArray(a *ast.ArrayLiteral) error {
 			return err
 		}
 
-		obj := e.results.pop()
+		obj, err := e.popForced()
+
+		if err != nil {
+			return err
+		}
 		objs = append(objs, obj)
 	}
 
@@ -331,22 +759,58 @@ func (e *Evaluator) VisitSlice(s *ast.SliceLiteral) error {
 			return err
 		}
 
-		obj := e.results.pop()
+		obj, err := e.popForced()
+
+		if err != nil {
+			return err
+		}
 		objs = append(objs, obj)
 	}
 
-	size := uint64(len(objs))
+	e.results.push(object.NewSlice(objs))
 
-	array := &object.Array{
-		Value: objs,
-		Size:  size,
+	return nil
+}
+
+func (e *Evaluator) VisitTuple(t *ast.TupleLiteral) error {
+	objs := []object.Object{}
+
+	for _, elem := range t.Elements {
+		if err := elem.Accept(e); err != nil {
+			return err
+		}
+
+		obj, err := e.popForced()
+
+		if err != nil {
+			return err
+		}
+		objs = append(objs, obj)
+	}
+
+	e.results.push(&object.Tuple{Elements: objs})
+
+	return nil
+}
+
+func (e *Evaluator) VisitUnpack(u *ast.Unpack) error {
+	if err := u.Tuple.Accept(e); err != nil {
+		return err
+	}
+
+	tuple, err := e.popForced()
+
+	if err != nil {
+		return err
 	}
 
-	slice := &object.Slice{
-		Value: array,
+	obj, err := Unpack(tuple, u.Index, u.Size)
+
+	if err != nil {
+		return err
 	}
 
-	e.results.push(slice)
+	e.results.push(obj)
 
 	return nil
 }
@@ -361,6 +825,14 @@ func (e *Evaluator) VisitInteger(n *ast.IntegerLiteral) error {
 	return nil
 }
 
+func (e *Evaluator) VisitBigInteger(n *ast.BigIntegerLiteral) error {
+	e.results.push(&object.BigInt{
+		Value: n.Value,
+	})
+
+	return nil
+}
+
 func (e *Evaluator) VisitFloat(n *ast.FloatLiteral) error {
 	result := &object.Float{
 		Value: float64(*n),
@@ -1,14 +1,42 @@
-package evaluator
+package evaluator_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	"raiton/compiler"
+	. "raiton/evaluator"
 	"raiton/lexer"
 	"raiton/object"
 	"raiton/parser"
 )
 
+type backend struct {
+	name string
+	new  func(env *object.Environment, opts ...Option) Backend
+}
+
+// The backends the cases run with, which have to agree on every result.
+var backends = []backend{
+	{"evaluator", func(env *object.Environment, opts ...Option) Backend {
+		e := New(env, opts...)
+		return &e
+	}},
+	{"compiler", func(env *object.Environment, opts ...Option) Backend {
+		c := compiler.New(env, opts...)
+		return &c
+	}},
+}
+
 func testEvaluation(env *object.Environment, input string) (object.Object, error) {
+	return testEvaluationOn(backends[0].new(env), input)
+}
+
+func testEvaluationOn(b Backend, input string) (object.Object, error) {
 	l := lexer.New(input)
 	p := parser.New(&l)
 	program, err := p.Parse()
@@ -17,9 +45,7 @@ func testEvaluation(env *object.Environment, input string) (object.Object, error
 		return nil, err
 	}
 
-	eval := New(env)
-
-	return eval.Evaluate(program)
+	return b.Evaluate(program)
 }
 
 func TestEvaluationInteger(t *testing.T) {
@@ -98,3 +124,1423 @@ func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
 
 	return true
 }
+
+// Evaluates the input with every backend, and returns what the first one
+// evaluated to, or an error if the others disagree with it.
+func testEvaluationWith(input string, opts ...Option) (object.Object, error) {
+	var expected object.Object
+	var expectedErr error
+
+	for i, b := range backends {
+		obj, err := testEvaluationOn(b.new(object.NewEnvironment(), opts...), input)
+
+		if i == 0 {
+			expected, expectedErr = obj, err
+			continue
+		}
+
+		if outcome(obj, err) != outcome(expected, expectedErr) {
+			return nil, fmt.Errorf("%s: got %s, but %s got %s", b.name, outcome(obj, err), backends[0].name, outcome(expected, expectedErr))
+		}
+	}
+
+	return expected, expectedErr
+}
+
+func outcome(obj object.Object, err error) string {
+	if err != nil {
+		return fmt.Sprintf("error %q", err)
+	}
+
+	return obj.Inspect()
+}
+
+func TestEvaluationLimits(t *testing.T) {
+	forever := `
+	fn forever x -> (add 1 (forever x))
+	(forever 1)
+	`
+
+	sleepy := DefaultBuiltins()
+	sleepy["sleep"] = object.MakeBuiltin(func(_ object.Runtime, _ ...object.Object) (object.Object, error) {
+		time.Sleep(20 * time.Millisecond)
+		return object.TRUE, nil
+	})
+
+	tests := []struct {
+		name     string
+		input    string
+		opts     []Option
+		expected error
+	}{
+		{"steps", forever, []Option{WithMaxSteps(100)}, ErrStepLimit},
+		{"depth", forever, []Option{WithMaxDepth(100)}, ErrDepthLimit},
+		{"timeout", `(sleep) (sleep)`, []Option{WithBuiltins(sleepy), WithTimeout(10 * time.Millisecond)}, ErrTimeout},
+		{"callback steps", `(fold (range 1000) 0 add)`, []Option{WithMaxSteps(100)}, ErrStepLimit},
+		{"callback timeout", `(map [1 2 3] sleep)`, []Option{WithBuiltins(sleepy), WithTimeout(10 * time.Millisecond)}, ErrTimeout},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input, tt.opts...)
+
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected error %q, but got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationCanceled(t *testing.T) {
+	l := lexer.New(`(add 1 2)`)
+	p := parser.New(&l)
+	program, err := p.Parse()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	eval := New(object.NewEnvironment())
+
+	if _, err := eval.EvaluateContext(ctx, program); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected error %q, but got %v", ErrCanceled, err)
+	}
+}
+
+func TestEvaluationConditional(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"if true { 1 } else { 2 }", 1},
+		{"if false { 1 } else { 2 }", 2},
+		{"if (eq 1 0) { 1 } else if (eq 0 0) { 2 } else { 3 }", 2},
+		{"x: if false { 1 } else { y: 5 (add y 1) } x", 6},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+
+	if _, err := testEvaluationWith("if 1 { 1 } else { 2 }"); err == nil {
+		t.Errorf("expected error for non-boolean condition")
+	}
+}
+
+func TestEvaluationTailCalls(t *testing.T) {
+	// deep enough to overflow the Go stack if tail calls were nested
+	iterations := int64(1_000_000)
+
+	if testing.Short() {
+		iterations = 100_000
+	}
+
+	input := fmt.Sprintf(`
+	fn count n acc {
+		if (eq n 0) { acc } else { (count (sub n 1) (add acc 1)) }
+	}
+
+	fn count_down n -> if (eq n 0) { 0 } else { (count_down (sub n 1)) }
+
+	(add (count %d 0) (count_down %d))
+	`, iterations, iterations)
+
+	evaluated, err := testEvaluationWith(input, WithMaxDepth(100))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testIntegerObject(t, evaluated, iterations)
+}
+
+func TestEvaluationScopeOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn f { (add 1 2) x: 5 } (f)", "3"},
+		{"fn g -> 4 fn f { (g) x: 5 } (f)", "4"},
+		{"fn f { x: 5 y: 6 } (f)", "6"},
+		{"fn count n { if (eq n 0) { 0 } else { (count (sub n 1)) } } fn f { r: (count 3) x: 1 } (f)", "1"},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	for _, b := range backends {
+		var stdout bytes.Buffer
+
+		_, err := testEvaluationOn(b.new(object.NewEnvironment(), WithStdout(&stdout)), `c: (ref 0) (set! c 1) x: (get c) (println x)`)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout.String() != "1\n" {
+			t.Errorf("%s: expected definitions to be evaluated in order, but got %q", b.name, stdout.String())
+		}
+	}
+}
+
+func TestEvaluationClosures(t *testing.T) {
+	input := `
+	fn adder n -> \x -> (add x n)
+	add_two: (adder 2)
+	n: 40
+	(add_two n)
+	`
+
+	evaluated, err := testEvaluationWith(input)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testIntegerObject(t, evaluated, 42)
+}
+
+// Returns builtins with `tick`, which counts how many times it was called,
+// and `boom`, which always fails.
+func lazyBuiltins(ticks *int) map[string]object.Object {
+	builtins := DefaultBuiltins()
+
+	builtins["tick"] = object.MakeBuiltin(func(_ object.Runtime, _ ...object.Object) (object.Object, error) {
+		*ticks += 1
+		return &object.Integer{Value: int64(*ticks)}, nil
+	})
+
+	builtins["boom"] = object.MakeBuiltin(func(_ object.Runtime, _ ...object.Object) (object.Object, error) {
+		return nil, fmt.Errorf("boom")
+	})
+
+	return builtins
+}
+
+func TestEvaluationLazyAndEagerAgree(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"x: (add 1 2) (add x x)", 6},
+		{"fn twice f x -> (f (f x)) (twice \\n -> (add n 1) 5)", 7},
+		{"fn count n acc { if (eq n 0) { acc } else { (count (sub n 1) (add acc 1)) } } (count 100 0)", 100},
+		{"t: lazy (add 20 1) (add (force t) t)", 42},
+		{"r: { a: (add 1 1) } s: [r.a 3] s.1", 3},
+	}
+
+	for _, tt := range tests {
+		for _, opts := range [][]Option{{}, {WithLazyEvaluation()}} {
+			var ticks int
+
+			opts = append(opts, WithBuiltins(lazyBuiltins(&ticks)))
+			evaluated, err := testEvaluationWith(tt.input, opts...)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testIntegerObject(t, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestEvaluationLazy(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		ticks    int
+		eager    bool
+	}{
+		// unused definitions and arguments are never evaluated
+		{"x: (boom) 5", 5, 0, false},
+		{"fn first a b -> a (first 1 (boom))", 1, 0, false},
+		// values are evaluated once and remembered
+		{"x: (tick) (add x x)", 2, 1, true},
+		{"fn double n -> (add n n) (double (tick))", 2, 1, true},
+		// explicit lazy expressions behave the same in both modes
+		{"t: lazy (tick) (add (force t) (force t))", 2, 1, true},
+		{"t: lazy (boom) 5", 5, 0, true},
+	}
+
+	for _, tt := range tests {
+		for _, b := range backends {
+			var ticks int
+
+			evaluated, err := testEvaluationOn(b.new(object.NewEnvironment(), WithBuiltins(lazyBuiltins(&ticks)), WithLazyEvaluation()), tt.input)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testIntegerObject(t, evaluated, tt.expected)
+
+			if ticks != tt.ticks {
+				t.Errorf("%s: %s: expected %d ticks, but got %d", b.name, tt.input, tt.ticks, ticks)
+			}
+
+			ticks = 0
+
+			_, err = testEvaluationOn(b.new(object.NewEnvironment(), WithBuiltins(lazyBuiltins(&ticks))), tt.input)
+
+			if tt.eager && err != nil {
+				t.Errorf("%s: %s: expected eager evaluation to succeed, but got %s", b.name, tt.input, err)
+			} else if !tt.eager && err == nil {
+				t.Errorf("%s: %s: expected eager evaluation to fail", b.name, tt.input)
+			}
+		}
+	}
+}
+
+func TestEvaluationLazyCycle(t *testing.T) {
+	_, err := testEvaluationWith("x: (add x 1) x", WithLazyEvaluation())
+
+	if err == nil || err.Error() != "lazy value `(add x 1 )` depends on itself" {
+		t.Fatalf("expected cycle error, but got %v", err)
+	}
+}
+
+func TestEvaluationSlotsAndNamesAgree(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn f x { y: (add x 1) y: (add y 1) y } (f 1)", "3"},
+		{"x: 10 fn f { y: x x: 5 (add x y) } (f)", "15"},
+		{"fn f { even: \\n -> if (eq n 0) { true } else { (odd (sub n 1)) } odd: \\n -> if (eq n 0) { false } else { (even (sub n 1)) } (even 7) } (f)", "false"},
+		{"fn outer a { fn inner b -> (add a b) (inner 2) } (outer 40)", "42"},
+		{"fn f c { if c { x: 1 } else { 2 } x } x: 7 (f false)", "7"},
+		{"fn compose f g -> \\x -> (f (g x)) ((compose \\x -> (add x 1) \\x -> (add x 2)) 0)", "3"},
+	}
+
+	for _, tt := range tests {
+		for _, opts := range [][]Option{{}, {WithDynamicLookup()}} {
+			evaluated, err := testEvaluationWith(tt.input, opts...)
+
+			if err != nil {
+				t.Fatalf("%s: %s", tt.input, err)
+			}
+
+			if evaluated.Inspect() != tt.expected {
+				t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestEvaluationTopLevelRedefinition(t *testing.T) {
+	env := object.NewEnvironment()
+
+	lines := []string{"x: 1", "fn f -> x", "x: 2", "(f)"}
+
+	var evaluated object.Object
+	var err error
+
+	for _, line := range lines {
+		if evaluated, err = testEvaluation(env, line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testIntegerObject(t, evaluated, 2)
+}
+
+func TestEvaluationArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(add 1 2 3)", "6"},
+		{"(add 1 2.5)", "3.5"},
+		{"(sub 10 2 3)", "5"},
+		{"(sub 1 0.5)", "0.5"},
+		{"(mul 2 3 4)", "24"},
+		{"(div 7 2)", "3"},
+		{"(div -7 2)", "-3"},
+		{"(div 7 2.0)", "3.5"},
+		{"(mod 7 3)", "1"},
+		{"(mod -7 3)", "-1"},
+		{"(mod 7.5 2)", "1.5"},
+		{"(neg 3)", "-3"},
+		{"(neg 1.5)", "-1.5"},
+		{"(abs -4)", "4"},
+		{"(abs -4.5)", "4.5"},
+		{"(min 3 1.5 2)", "1.5"},
+		{"(max 3 1 3.0)", "3"},
+		{"(eq 1 1.0 1)", "true"},
+		{"(eq [1 [2 3]] [1 [2 3]])", "true"},
+		{"(eq [1 2] [2: 1 2])", "false"},
+		{"(eq { a: 1 b: [2] } { b: [2] a: 1 })", "true"},
+		{"(eq { a: 1 } { a: 1 b: 2 })", "false"},
+		{"(eq \\x -> x \\x -> x)", "false"},
+		{"(neq 1 2)", "true"},
+		{"(lt 1 2 3)", "true"},
+		{"(lt 1 3 2)", "false"},
+		{"(le 1 1 2.5)", "true"},
+		{"(gt \"b\" \"a\")", "true"},
+		{"(ge 'a' 'b')", "false"},
+		{"(not false)", "true"},
+		{"(and true true false)", "false"},
+		{"(or false true)", "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationArithmeticErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(div 1 0)", "division by zero"},
+		{"(div 1.5 0)", "division by zero"},
+		{"(mod 1 0)", "division by zero"},
+		{"(add 1 true)", "expected argument 2 to be a number, but got boolean"},
+		{"(divmod 1 2 3)", "expected 2 arguments, but got 3"},
+		{"(neg 1 2)", "expected one argument, but got 2"},
+		{"(lt 1 \"a\")", "cannot compare integer with string"},
+		{"(not 1)", "expected argument 1 to be a boolean, but got integer"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationBigIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		big      bool
+	}{
+		{"(add 9223372036854775807 1)", "9223372036854775808", true},
+		{"(sub -9223372036854775808 1)", "-9223372036854775809", true},
+		{"(mul 4294967296 4294967296)", "18446744073709551616", true},
+		{"(div -9223372036854775808 -1)", "9223372036854775808", true},
+		{"(neg -9223372036854775808)", "9223372036854775808", true},
+		{"(abs -9223372036854775808)", "9223372036854775808", true},
+		{"(sub 9223372036854775808 1)", "9223372036854775807", false},
+		{"(div 18446744073709551616 4294967296)", "4294967296", false},
+		{"(mod -18446744073709551617 10)", "-7", false},
+		{"(add 18446744073709551616 0.5)", "1.8446744073709552e+19", false},
+		{"123456789012345678901234567890", "123456789012345678901234567890", true},
+		{"-123456789012345678901234567890", "-123456789012345678901234567890", true},
+		{"(eq 9223372036854775808 (add 9223372036854775807 1))", "true", false},
+		{"(lt 9223372036854775807 9223372036854775808 18446744073709551616.5)", "true", false},
+		{"(max 1 18446744073709551616 2.5)", "18446744073709551616", true},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+
+		if _, ok := evaluated.(*object.BigInt); ok != tt.big {
+			t.Errorf("%s: expected big integer to be %t, but got %T", tt.input, tt.big, evaluated)
+		}
+	}
+}
+
+func TestEvaluationFactorial(t *testing.T) {
+	input := `
+	fn factorial n {
+		if (eq n 0) { 1 } else { (mul n (factorial (sub n 1))) }
+	}
+	(factorial 30)
+	`
+
+	evaluated, err := testEvaluationWith(input)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "265252859812191058636308480000000"
+
+	if evaluated.Inspect() != expected {
+		t.Errorf("expected %s, but got %s", expected, evaluated.Inspect())
+	}
+}
+
+func TestEvaluationStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(concat "Rai" "ton" '!')`, `"Raiton!"`},
+		{`(str.len "héllo")`, "5"},
+		{`(str.split "a,b,,c" ",")`, `["a" "b" "" "c"]`},
+		{`(str.split "añb" "")`, `["a" "ñ" "b"]`},
+		{`(str.join ["a" 'b' "c"] ", ")`, `"a, b, c"`},
+		{`(str.trim "  raiton\n ")`, `"raiton"`},
+		{`(str.replace "a-b-c" "-" "+")`, `"a+b+c"`},
+		{`(str.contains "raiton" "ait")`, "true"},
+		{`(str.starts_with "raiton" "rai")`, "true"},
+		{`(str.ends_with "raiton" "rai")`, "false"},
+		{`(str.upper "héllo")`, `"HÉLLO"`},
+		{`(str.lower "ÀB")`, `"àb"`},
+		{`(str.substring "héllo" 1 4)`, `"éll"`},
+		{`(str.substring "héllo" 5 5)`, `""`},
+		{`(str.index_of "héllo" "l")`, "2"},
+		{`(str.index_of "héllo" "x")`, "-1"},
+		{`(str.repeat "ab" 3)`, `"ababab"`},
+		{`(str.repeat "" 9999999999999)`, `""`},
+		{`(str.chars "añ")`, `['a' 'ñ']`},
+		{`(str.from_chars ['a' 'ñ'])`, `"añ"`},
+		{`(str.to_number "-42")`, "(Ok -42)"},
+		{`(str.to_number "2.5")`, "(Ok 2.5)"},
+		{`(str.to_number "123456789012345678901234567890")`, "(Ok 123456789012345678901234567890)"},
+		{`(str.to_number "12a")`, `(Err (error "value" "cannot convert "12a" to a number" { input: "12a" }))`},
+		{`(str.from_number 2.5)`, `"2.5"`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(concat "a" 1)`, "expected argument 2 to be a string, but got integer"},
+		{`(str.len "a" "b")`, "expected one argument, but got 2"},
+		{`(str.split "a")`, "expected 2 arguments, but got 1"},
+		{`(str.join ["a" 1] "")`, "expected element 1 to be a string, but got integer"},
+		{`(str.substring "abc" 2 4)`, "range 2..4 is out of bounds for a string of length 3"},
+		{`(str.substring "abc" "a" 2)`, "expected argument 2 to be an integer, but got string"},
+		{`(str.repeat "a" -1)`, "expected a non-negative count, but got -1"},
+		{`(str.repeat "ab" 9999999999999)`, "repeating a string of length 2 9999999999999 times exceeds the maximum length of 268435456"},
+		{`(str.from_chars ['a' "b"])`, "expected element 1 to be a character, but got string"},
+		{`(str.to_number 12)`, "expected argument 1 to be a string, but got integer"},
+		{`(str.from_number "1")`, "expected argument 1 to be a number, but got string"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationPrint(t *testing.T) {
+	for _, b := range backends {
+		var stdout bytes.Buffer
+
+		evaluated, err := testEvaluationOn(b.new(object.NewEnvironment(), WithStdout(&stdout)), `(print "a" 'b' 1) (println "" [1 "c"])`)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout.String() != "a b 1 [1 \"c\"]\n" {
+			t.Errorf("%s: unexpected output %q", b.name, stdout.String())
+		}
+
+		if evaluated.Inspect() != `[1 "c"]` {
+			t.Errorf("%s: expected the last argument to be returned, but got %s", b.name, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationIteration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(map (range 3) \x -> (mul x x))`, "[3: 0 1 4]"},
+		{`(map "ab" str.upper)`, `[2: "A" "B"]`},
+		{`(filter [1 2 3 4] \x -> (eq (mod x 2) 0))`, "[2 4]"},
+		{`(filter "a1b2" \c -> (str.contains "0123456789" c))`, "['1' '2']"},
+		{`(fold [1 2 3] 10 add)`, "16"},
+		{`(fold [1 2 3] [] \acc x -> [x acc])`, "[3 [2 [1 []]]]"},
+		{`(reduce (range 1 5) mul)`, "24"},
+		{`(zip [1 2 3] "ab")`, "[(1, 'a') (2, 'b')]"},
+		{`(zip (range 2) (range 10 20) (range 20 30))`, "[(0, 10, 20) (1, 11, 21)]"},
+		{`(enumerate "hi")`, "[(0, 'h') (1, 'i')]"},
+		{`(range 5)`, "(range 0 5)"},
+		{`(take (range 10 0 -3) 10)`, "[10 7 4 1]"},
+		{`(take [1 2 3] 2)`, "[1 2]"},
+		{`(drop [1 2 3] 2)`, "[3]"},
+		{`(drop [1 2 3] 5)`, "[]"},
+		{`(any [1 2 3] \x -> (gt x 2))`, "true"},
+		{`(any [] \x -> (gt x 2))`, "false"},
+		{`(all [1 2 3] \x -> (gt x 0))`, "true"},
+		{`(all [1 2 3] \x -> (gt x 1))`, "false"},
+		{`(find [1 2 3] \x -> (gt x 1))`, "(Some 2)"},
+		{`(find [1 2] \x -> false)`, "None"},
+		{`(find [1 2 3] \x -> (gt x 5) 0)`, "0"},
+		{`(flat_map [1 2 3] \x -> (range x))`, "[0 0 1 0 1 2]"},
+		{`g: (group_by [1 2 3 4 5] \x -> if (eq (mod x 2) 0) { "even" } else { "odd" }) [g.("even") g.("odd")]`, "[[2 4] [1 3 5]]"},
+		{`(group_by [1 "1" '1'] \x -> x)`, `%{ 1: [1] "1": ["1"] '1': ['1'] }`},
+		{`(group_by ["a b" "c"] \x -> x)`, `%{ "a b": ["a b"] "c": ["c"] }`},
+		{`(sort [3 1 2])`, "[1 2 3]"},
+		{`(sort ["b" "c" "a"] gt)`, `["c" "b" "a"]`},
+		{`(sort [[2 "b"] [1 "x"] [2 "a"]] \a b -> (sub a.0 b.0))`, `[[1 "x"] [2 "b"] [2 "a"]]`},
+		{`(map { b: 2 a: 1 } \pair -> pair.0)`, `[2: "b" "a"]`},
+		{`(map (enumerate "ab") \(i, c) -> i)`, `[2: 0 1]`},
+		{`(map (zip [1 2] "ab") \(n, c) -> c)`, `[2: 'a' 'b']`},
+		{`(map (to_pairs { a: 1 }) \(k, v) -> v)`, `[1: 1]`},
+		{`(map %{ 1: "x" } \(k, v) -> v)`, `[1: "x"]`},
+		{`(from_pairs (to_pairs { a: 1 b: 2 }))`, `{ a: 1 b: 2 }`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationIterationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(map 1 \x -> x)`, "expected argument 1 to be iterable, but got integer"},
+		{`(map [1] 1)`, "expected argument 2 to be a function, but got integer"},
+		{`(map [1] \x -> x 1)`, "expected 2 arguments, but got 3"},
+		{`(force 1 2)`, "expected one argument, but got 2"},
+		{`(filter 1 \x -> true)`, "expected argument 1 to be iterable, but got integer"},
+		{`(filter [1] 1)`, "expected argument 2 to be a function, but got integer"},
+		{`(filter [1] \x -> x)`, "expected predicate to return a boolean, but got integer"},
+		{`(reduce [] add)`, "cannot reduce an empty collection"},
+		{`(range 1 2 0)`, "expected a non-zero step"},
+		{`(take [1] -1)`, "expected a non-negative count, but got -1"},
+		{`(flat_map [1] \x -> x)`, "expected function to return an iterable, but got integer"},
+		{`(group_by [1] \x -> [x])`, "cannot group by slice keys"},
+		{`(sort [1 "a"])`, "cannot compare string with integer"},
+		{`(sort [1 2] \a b -> "a")`, "expected comparator to return a boolean or an integer, but got string"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationEach(t *testing.T) {
+	for _, b := range backends {
+		var stdout bytes.Buffer
+
+		evaluated, err := testEvaluationOn(b.new(object.NewEnvironment(), WithStdout(&stdout)), `(each (range 3) println)`)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout.String() != "0\n1\n2\n" {
+			t.Errorf("%s: unexpected output %q", b.name, stdout.String())
+		}
+
+		if evaluated.Inspect() != "(range 0 3)" {
+			t.Errorf("%s: expected the collection to be returned, but got %s", b.name, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationSlices(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(slice [4: 1 2 3 4] 1 3)`, "[2 3]"},
+		{`(slice [1 2 3 4] 2)`, "[3 4]"},
+		{`(slice [1 2 3 4] -3 -1)`, "[2 3]"},
+		{`(slice [1 2 3 4] -2)`, "[3 4]"},
+		{`s: (slice [1 2 3 4] 1 2) [(len s) (cap s)]`, "[1 3]"},
+		{`s: (slice [1 2 3 4] 1 2) (slice s 0 3)`, "[2 3 4]"},
+		{`s: (slice [1 2 3 4] 1 3) s.1`, "3"},
+		{`a: [3: 1 2 3] s: (append (slice a 0 1) 9) [a s]`, "[[3: 1 9 3] [1 9]]"},
+		{`s: (append [1 2] 3) t: (append s 4) u: (append s 5) [t u]`, "[[1 2 3 5] [1 2 3 5]]"},
+		{`s: (append [1 2 3] 4) [(len s) (cap s)]`, "[4 6]"},
+		{`(append [] 1 2)`, "[1 2]"},
+		{`(len "héllo")`, "5"},
+		{`(len { a: 1 b: 2 })`, "2"},
+		{`(len (range 0 10 3))`, "4"},
+		{`(len (range 10 0 -3))`, "4"},
+		{`(len (range 5 0))`, "0"},
+		{`(cap [3: 1 2 3])`, "3"},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationSelectors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a: [4: 1 2 3 4] i: 2 a.(i)`, "3"},
+		{`a: [4: 1 2 3 4] i: 2 a.(sub i 1)`, "2"},
+		{`a: [1 2 3 4] a.-1`, "4"},
+		{`a: [1 2 3 4] a.(neg 4)`, "1"},
+		{`a: [4: 1 2 3 4] a.1..3`, "[2 3]"},
+		{`a: [1 2 3 4] a.1..-1`, "[2 3]"},
+		{`a: [1 2 3 4] a.2..4`, "[3 4]"},
+		{`a: [1 2 3 4] a.2..2`, "[]"},
+		{`a: [1 2 3 4] i: 1 a.(i)..(add i 2)`, "[2 3]"},
+		{`a: [4: 1 2 3 4] b: a.0..2 (append b 9) a`, "[4: 1 2 9 4]"},
+		{`a: [[1 2] [3 4]] a.-1.0`, "3"},
+		{`s: "héllo" [s.1 s.-1 s.1..3]`, `['é' 'o' "él"]`},
+		{`r: { name: "x" } field: "name" r.(field)`, `"x"`},
+		{`m: %{ "k": 1 [2: 1 2]: 2 } [m.("k") m.([2: 1 2])]`, "[1 2]"},
+		{`fn at xs i -> xs.(i) (at [5 6 7] -2)`, "6"},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationSelectorErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a: [1 2] a.-3`, "index -3 is out of bounds for length 2"},
+		{`a: [1 2] i: 5 a.(i)`, "index 5 is out of bounds for length 2"},
+		{`a: [1 2] a.(add 9223372036854775807 1)`, "index 9223372036854775808 is out of bounds for length 2"},
+		{`s: "ab" s.2`, "index 2 is out of bounds for length 2"},
+		{`a: [1 2] a.("x")`, "can only access elements with indexes, but got string"},
+		{`r: { a: 1 } r.0`, "can only access record fields with names, but got integer"},
+		{`m: %{ 1: 2 } m.(3)`, "key 3 not found in map"},
+		{`a: [1 2] a.2..1`, "range 2..1 is out of bounds for length 2"},
+		{`a: [1 2] a.0..3`, "range 0..3 is out of bounds for length 2"},
+		{`a: [1 2] a.0..("x")`, "can only select ranges with indexes, but got string"},
+		{`r: { a: 1 } r.0..1`, "expected an array, a slice or a string to select a range of, but got record"},
+		{`x: 1 x.0`, "expected a collection but got integer"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationTuples(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(1, "a", 'b')`, `(1, "a", 'b')`},
+		{`(1,)`, `(1,)`},
+		{`t: (1, (2, 3)) [t.0 t.1.1 t.-1 (len t)]`, `[1 3 (2, 3) 2]`},
+		{`(divmod 7 2)`, `(3, 1)`},
+		{`(q, r): (divmod 17 5) (add (mul q 5) r)`, `17`},
+		{`((a, b), c): ((1, 2), 3) [a b c]`, `[1 2 3]`},
+		{`fn swap (x, y) -> (y, x) (swap (1, 2))`, `(2, 1)`},
+		{`(map [(1, 2) (3, 4)] \(x, y) -> (add x y))`, `[2: 3 7]`},
+		{`fn k n { (a, b): (n, (mul n 2)) (add a b) } (k 5)`, `15`},
+		{`[(eq (1, 2) (1, 2.0)) (eq (1, 2) [2: 1 2]) (eq (1, 2) (1, 2, 3))]`, `[true false false]`},
+		{`m: %{ (1, 'a'): "x" [2: 1 'a']: "y" } [(get m (1, 'a') "") (get m [2: 1 'a'] "") (len m)]`, `["x" "y" 2]`},
+		{`(map (1, 2) \x -> (mul x 10))`, `[2: 10 20]`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationTupleErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(a, b): (1, 2, 3) a`, "cannot destructure a tuple of 3 elements into 2 names"},
+		{`(a, b): [1 2] a`, "cannot destructure slice into a tuple of 2 elements"},
+		{`fn f (x, y) -> x (f 1)`, "cannot destructure integer into a tuple of 2 elements"},
+		{`t: (1, 2) t.2`, "index 2 is out of bounds for length 2"},
+		{`%{ (1, [2]): 1 }`, "cannot use tuple as a map key"},
+		{`(divmod 1 0)`, "division by zero"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationPartialApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(map [1 2 3] (add 1))`, `[3: 2 3 4]`},
+		{`fn plus a b -> (add a b) inc: (plus 1) (inc 2)`, `3`},
+		{`fn plus3 a b c -> (add a b c) [((plus3 1) 2 3) (((plus3 1) 2) 3) ((plus3 1 2) 3)]`, `[6 6 6]`},
+		{`fn adder n -> \x -> (add n x) (adder 1 2)`, `3`},
+		{`fn compose f g -> \x -> (f (g x)) (compose (mul 2) (add 1) 3)`, `8`},
+		{`(filter [1 5 2 7] (lt 3))`, `[5 7]`},
+		{`(fold [[1 2] [3]] 0 \acc xs -> (fold xs acc add))`, `6`},
+		{`fn plus a b -> (add a b) (plus 1)`, `(\a b { (add a b ) } 1)`},
+		{`(add 1)`, `(builtin function 1)`},
+		{`fn plus a b -> (add a b) (plus)`, `\a b { (add a b ) }`},
+		{`(add 1 2 3)`, `6`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationOverApplicationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fn plus a b -> (add a b) (plus 1 2 3)`, "function expects 2 arguments, but got 3"},
+		{`fn id x -> x (id add 1 "a")`, "expected argument 2 to be a number, but got string"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fn connect host port: 80 -> [host port] [(connect "a") (connect "a" 8080)]`, `[["a" 80] ["a" 8080]]`},
+		{`fn connect host port: 80 -> [host port] (connect port: 1 host: "b")`, `["b" 1]`},
+		{`fn f x y: (mul x 2) -> (add x y) [(f 1) (f 1 1)]`, `[3 2]`},
+		{`fn sum first ...rest -> (fold rest first add) [(sum 1) (sum 1 2 3)]`, `[1 6]`},
+		{`fn tail _ ...rest -> rest (tail 1 2 3)`, `[2 3]`},
+		{`fn opts a b: 2 c: 3 -> [a b c] (opts 1 c: 4)`, `[1 2 4]`},
+		{`fn count n acc: 0 -> if (eq n 0) { acc } else { (count (sub n 1) (add acc 1)) } (count 1000)`, `1000`},
+		{`fn plus a b -> (add a b) ((plus 1) b: 2)`, `3`},
+		{`\a b: 1 ...rest -> a`, `\a b: 1 ...rest { a }`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationParameterErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fn f a b: 1 -> a (f 1 2 3)`, "function expects 1 to 2 arguments, but got 3"},
+		{`fn f a b: 1 -> a (f b: 2)`, "missing argument for parameter 'a'"},
+		{`fn f a -> a (f 1 a: 2)`, "parameter 'a' is bound more than once"},
+		{`fn f a -> a (f b: 2)`, "function has no parameter named 'b'"},
+		{`fn f ...xs -> xs (f xs: [1])`, "cannot bind the rest parameter 'xs' by name"},
+		{`(add 1 x: 2)`, "builtin functions don't take named arguments"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationPipelines(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1 2 3] |> (map \x -> (mul x x)) |> (fold 0 add)`, `14`},
+		{`"raiton" |> str.upper`, `"RAITON"`},
+		{`fn connect host port: 80 -> [host port] "x" |> (connect port: 1)`, `["x" 1]`},
+		{`f: (add 1) >> (mul 2) (f 3)`, `8`},
+		{`f: (add 1) >> (mul 2) >> str.from_number 4 |> f`, `"10"`},
+		{`((compose add neg) 1 2)`, `-3`},
+		{`((compose (add 1)) 2)`, `3`},
+		{`((compose (add 1) (mul 2) neg) 2)`, `-6`},
+		{`3 |> (add 1) >> (mul 2)`, `8`},
+		{`fn compose a b -> "hijacked" f: (add 1) >> (mul 2) (f 3)`, `8`},
+		{`fn f compose -> (add 1) >> (mul 2) ((f 0) 3)`, `8`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationReferences(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`r: (ref 1) (set! r 2) (get r)`, `2`},
+		{`r: (ref 1) r := (add (get r) 1) r`, `(ref 2)`},
+		{`r: (ref 0) fn tick { r := (add (get r) 1) } (tick) (tick) (get r)`, `2`},
+		{`r: (ref 0) (each [1 2 3] \x -> r := (add (get r) x)) (get r)`, `6`},
+		{`r: (ref 0) (set! r 1) x: (get r) x`, `1`},
+		{`c: (ref 0) (set! c 1) x: (get c) c := 2 [x (get c)]`, `[1 2]`},
+		{`fn f { c: (ref 0) c := 1 x: (get c) c := 2 [x (get c)] } (f)`, `[1 2]`},
+		{`fn f set! { c: (ref 1) c := 2 } (f 0)`, `2`},
+		{`set!: \r v -> 0 c: (ref 1) c := 2`, `2`},
+		{`r: (ref []) [(eq r r) (eq r (ref []))]`, `[true false]`},
+		{`(get %{ "a": 1 } "a")`, `(Some 1)`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationReferenceErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(get 1)`, "expected argument 1 to be a reference, but got integer"},
+		{`(set! [1] 2)`, "expected argument 1 to be a reference, but got slice"},
+		{`x: 1 x := 2`, "expected argument 1 to be a reference, but got integer"},
+		{`(ref)`, "expected one argument, but got 0"},
+		{`(get %{} 1 2 3)`, "expected 1 to 3 arguments, but got 4"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`r: (ref 0) for x in [1 2 3] { r := (add (get r) x) } (get r)`, `6`},
+		{`r: (ref 0) for x in (range 10) { if (eq (mod x 2) 0) { continue } else { x } r := (add (get r) x) } (get r)`, `25`},
+		{`r: (ref 0) for x in (range 10) { if (gt x 3) { break } else { x } r := (add (get r) x) } (get r)`, `6`},
+		{`r: (ref "") for c in "abc" { r := (concat c (get r)) } (get r)`, `"cba"`},
+		{`r: (ref "") for field in { a: 1 b: 2 } { r := (concat (get r) field.0) } (get r)`, `"ab"`},
+		{`r: (ref 0) for (a, b) in [(1, 2) (3, 4)] { r := (add (get r) (mul a b)) } (get r)`, `14`},
+		{`r: (ref "") for (k, v) in { a: 1 b: 2 } { r := (concat (get r) k) } (get r)`, `"ab"`},
+		{`r: (ref 0) for x in [1 2] { for y in [10 20] { if (eq y 20) { break } else { y } r := (add (get r) x y) } } (get r)`, `23`},
+		{`fs: (ref []) for x in [1 2] { fs := (append (get fs) \ -> x) } (map (get fs) \f -> (f))`, `[2: 1 2]`},
+		{`for x in [1 2 3] { x }`, `[1 2 3]`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationLoopErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`for x in 1 { x }`, "expected a collection to loop over, but got integer"},
+		{`for (a, b) in [1] { a }`, "cannot destructure integer into a tuple of 2 elements"},
+		{`for x in [1 2] { x.a }`, "expected a collection but got integer"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationTry(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { 1 } catch e { 2 }`, `1`},
+		{`try { (raise "boom") } catch e { [e.kind e.message] }`, `["error" "boom"]`},
+		{`try { (raise "not_found" "no user" { id: 3 }) } catch e { e }`, `(error "not_found" "no user" { id: 3 })`},
+		{`try { (raise "not_found" "no user" { id: 3 }) } catch e { e.payload.id }`, `3`},
+		{`try { (raise "a") } catch e { try { (raise e) } catch f { (eq e f) } }`, `true`},
+		{`fn f x { (raise "inner") } try { (f 1) } catch e { e.message }`, `"inner"`},
+		{`r: (ref 0) try { (raise "a") } catch e { r := 1 } finally { r := (add (get r) 10) } (get r)`, `11`},
+		{`r: (ref 0) try { 1 } finally { r := 5 } (get r)`, `5`},
+		{`r: (ref 0) try { try { (raise "a") } finally { r := 1 } } catch e { (get r) }`, `1`},
+		{`r: (ref 0) for x in [1 2 3] { try { if (eq x 2) { break } else { x } } finally { r := x } } (get r)`, `2`},
+		{`try { try { (raise "a") } catch e { (raise "b") } } catch e { e.message }`, `"b"`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationCaughtBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`r: { a: 1 } try { r.b } catch e { e }`, `(error "field" "field 'b' not defined on record")`},
+		{`m: %{ 1: 2 } try { m.(3) } catch e { e }`, `(error "key" "key 3 not found in map")`},
+		{`try { (put %{} 1.5 1) } catch e { e }`, `(error "key" "cannot use float as a map key")`},
+		{`xs: [1 2] try { xs.(5) } catch e { e }`, `(error "index" "index 5 is out of bounds for length 2")`},
+		{`try { (slice [1 2] -3) } catch e { e }`, `(error "index" "index -3 is out of bounds for length 2")`},
+		{`try { (div 1 0) } catch e { e }`, `(error "arithmetic" "division by zero")`},
+		{`try { (add 1 "a") } catch e { e }`, `(error "type" "expected argument 2 to be a number, but got string")`},
+		{`try { (\a -> a 1 2) } catch e { e }`, `(error "argument" "function expects 1 arguments, but got 2")`},
+		{`try { (str.repeat "a" -1) } catch e { e }`, `(error "value" "expected a non-negative count, but got -1")`},
+		{`try { (str.repeat "ab" 5000000000000000000) } catch e { e.kind }`, `"value"`},
+		{`try { nothing } catch e { e }`, `(error "name" "'nothing' not defined")`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationRaiseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(raise "boom")`, "boom"},
+		{`try { (raise "a") } catch e { e.line }`, "field 'line' not defined on error"},
+		{`(raise 1)`, "expected argument 1 to be a string, but got integer"},
+		{`(raise)`, "expected 1 to 3 arguments, but got 0"},
+		{`try { 1 } finally { (raise "late") }`, "late"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationVariants(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[(Ok 1) (Err "no") (Some [1]) None]`, `[(Ok 1) (Err "no") (Some [1]) None]`},
+		{`[(eq (Ok [1]) (Ok [1])) (eq (Ok 1) (Err 1)) (eq None None) (eq (Some 1) None)]`, `[true false true false]`},
+		{`[(map (Ok 1) \x -> (add x 1)) (map (Err 1) \x -> (add x 1)) (map (Some 1) \x -> (add x 1)) (map None \x -> x)]`, `[(Ok 2) (Err 1) (Some 2) None]`},
+		{`[(and_then (Some 2) \x -> (Some (mul x 3))) (and_then (Some 2) \x -> None) (and_then (Err 1) \x -> (Ok x))]`, `[(Some 6) None (Err 1)]`},
+		{`[(unwrap_or (Ok 1) 0) (unwrap_or (Err 1) 0) (unwrap_or None 0)]`, `[1 0 0]`},
+		{`[(unwrap (Some 1)) (unwrap_err (Err 2)) (is_ok (Ok 1)) (is_ok (Err 1)) (is_some None)]`, `[1 2 true false false]`},
+		{`(Some 1) |> (map \x -> (add x 1)) |> (unwrap_or 0)`, `2`},
+		{`fn f s { n: (str.to_number s)? (Ok (add n 1)) } e: (unwrap_err (f "a")) [(f "1") e.kind]`, `[(Ok 2) "value"]`},
+		{`fn f m { a: (get m "a")? b: (get m "b")? (Some (add a b)) } [(f %{ "a": 1 "b": 2 }) (f %{ "a": 1 })]`, `[(Some 3) None]`},
+		{`fn f o -> (add o?? 1) [(f (Some (Ok 1))) (f (Some (Err 2)))]`, `[2 (Err 2)]`},
+		{`fn f xs { for x in xs { x? } (Some xs) } [(f [(Some 1)]) (f [(Some 1) None])]`, `[(Some [(Some 1)]) None]`},
+		{`fn f o { try { (raise "x") } catch e { o? } } [(f (Some 1)) (f None)]`, `[1 None]`},
+		{`r: (ref 0) fn f o { try { o? } finally { r := (add (get r) 1) } } [(f None) (get r)]`, `[None 1]`},
+		{`fn g o -> o? fn f o { x: (g o) (Some x) } (f None)`, `(Some None)`},
+		{`fn count n o { x: o? if (eq n 0) { (Some x) } else { (count (sub n 1) o) } } [(count 10000 (Some 1)) (count 10000 None)]`, `[(Some 1) None]`},
+		{`fn f s { n: (str.to_number s)? (Ok n) } (map ["1" "x"] f)`, `[2: (Ok 1) (Err (error "value" "cannot convert "x" to a number" { input: "x" }))]`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationVariantErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(unwrap None)`, "cannot unwrap None"},
+		{`(unwrap (Err 1))`, "cannot unwrap (Err 1)"},
+		{`(unwrap (str.to_number "a"))`, `cannot convert "a" to a number`},
+		{`(unwrap_err (Ok 1))`, "cannot unwrap the error of (Ok 1)"},
+		{`(unwrap_or 1 0)`, "expected argument 1 to be a result or an option, but got integer"},
+		{`(and_then (Ok 1) \x -> (Some x))`, "expected function to return a result, but got option"},
+		{`(is_ok None)`, "expected argument 1 to be a result, but got option"},
+		{`(Ok)`, "expected one argument, but got 0"},
+		{`fn f x -> x? (f 1)`, "expected a result or an option to propagate, but got integer"},
+		{`fn f s { lazy (str.to_number s)? } (force (f "a"))`, "cannot return early from a function which has returned already"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationSliceErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a: [1 2] a.2`, "index 2 is out of bounds for length 2"},
+		{`a: [2: 1 2] a.2`, "index 2 is out of bounds for length 2"},
+		{`s: (slice [1 2 3] 0 1) s.1`, "index 1 is out of bounds for length 1"},
+		{`(slice [1 2 3] 2 1)`, "slice bounds 2..1 are out of range for capacity 3"},
+		{`(slice (slice [1 2 3] 1) 0 3)`, "slice bounds 0..3 are out of range for capacity 2"},
+		{`(slice [1 2 3] -4 2)`, "index -4 is out of bounds for length 3"},
+		{`(slice [1 2 3] -1 1)`, "slice bounds 2..1 are out of range for capacity 3"},
+		{`(slice "abc" 0 1)`, "expected argument 1 to be an array or a slice, but got string"},
+		{`(len 1)`, "expected argument 1 to be a collection, but got integer"},
+		{`(cap "a")`, "expected argument 1 to be an array or a slice, but got string"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationRecordOrder(t *testing.T) {
+	var stdout bytes.Buffer
+
+	input := `{ z: (println "z") a: (println "a") m: { y: 1 b: 2 } }`
+
+	for i := 0; i < 10; i++ {
+		for _, b := range backends {
+			stdout.Reset()
+
+			evaluated, err := testEvaluationOn(b.new(object.NewEnvironment(), WithStdout(&stdout)), input)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if stdout.String() != "z\na\n" {
+				t.Fatalf("%s: expected fields to be evaluated in order, but got %q", b.name, stdout.String())
+			}
+
+			expected := `{ z: "z" a: "a" m: { y: 1 b: 2 } }`
+
+			if evaluated.Inspect() != expected {
+				t.Fatalf("%s: expected %s, but got %s", b.name, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestEvaluationRecordUpdates(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`p: { name: "Ana" age: 30 } [{ p | age: 31 } p]`, `[{ name: "Ana" age: 31 } { name: "Ana" age: 30 }]`},
+		{`p: { a: { b: 1 } } { p.a | b: 2 }`, `{ b: 2 }`},
+		{`p: { a: 1 } { p | }`, `{ a: 1 }`},
+		{`p: { a: 1 b: 2 } { ...p c: 3 }`, `{ a: 1 b: 2 c: 3 }`},
+		{`p: { a: 1 b: 2 } { b: 0 ...p a: 5 }`, `{ b: 2 a: 5 }`},
+		{`{ ...{ a: 1 } ...{ b: 2 a: 3 } }`, `{ a: 3 b: 2 }`},
+		{`(merge { a: 1 b: 2 } { c: 3 b: 4 })`, `{ a: 1 b: 4 c: 3 }`},
+		{`(fields { b: 1 a: 2 })`, `["b" "a"]`},
+		{`r: { a: 1 } [(has_field r "a") (has_field r 'b')]`, `[true false]`},
+		{`r: { a: 1 b: 2 } [(remove_field r "a") r]`, `[{ b: 2 } { a: 1 b: 2 }]`},
+		{`(to_pairs { b: 1 a: 2 })`, `[("b", 1) ("a", 2)]`},
+		{`(from_pairs [[2: "b" 1] ["a" 2] [2: 'c' 3] ["b" 4]])`, `{ b: 4 a: 2 c: 3 }`},
+		{`r: { a: 1 b: [2] } (eq (from_pairs (to_pairs r)) r)`, `true`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationPipelineErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(compose)`, "expected at least 1 argument, but got 0"},
+		{`(compose (add 1) 2)`, "expected argument 2 to be a function, but got integer"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationRecordUpdateErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`p: { age: 30 } { p | agee: 31 }`, "cannot update field 'agee', which is not defined on the record"},
+		{`{ [1] | a: 1 }`, "expected a record to update, but got slice"},
+		{`{ ...%{ "a": 1 } }`, "cannot spread map into a record"},
+		{`(merge { a: 1 } 2)`, "expected argument 2 to be a record, but got integer"},
+		{`(merge)`, "expected at least 1 argument, but got 0"},
+		{`(remove_field { a: 1 } "b")`, "field 'b' not defined on record"},
+		{`(has_field { a: 1 } 1)`, "expected argument 2 to be a string, but got integer"},
+		{`(from_pairs [[1 2 3]])`, "expected element 0 to be a pair, but got [1 2 3]"},
+		{`(from_pairs [[1 2]])`, "expected the name of pair 0 to be a string, but got integer"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEvaluationMaps(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`%{ "b": 1 'a': 2 3: 3 true: 4 [2: 1 "x"]: 5 }`, `%{ "b": 1 'a': 2 3: 3 true: 4 [2: 1 "x"]: 5 }`},
+		{`%{ "a": 1 "b": 2 "a": 3 }`, `%{ "a": 3 "b": 2 }`},
+		{`%{}`, `%{  }`},
+		{`m: %{ 1: "one" } (get m 1)`, `(Some "one")`},
+		{`m: %{ 1: "one" } (get m 2)`, `None`},
+		{`m: %{ 1: "one" } (get m 2 "none")`, `"none"`},
+		{`m: %{ 9223372036854775808: "big" } (get m (add 9223372036854775807 1))`, `(Some "big")`},
+		{`m: %{ [2: 1 2]: "pair" } (get m [2: 1 2] "")`, `"pair"`},
+		{`m: %{ 1: "one" } [(put m 2 "two") m]`, `[%{ 1: "one" 2: "two" } %{ 1: "one" }]`},
+		{`m: %{ 1: "one" 2: "two" } [(delete m 1) m]`, `[%{ 2: "two" } %{ 1: "one" 2: "two" }]`},
+		{`m: %{ 'a': 1 } [(has m 'a') (has m "a")]`, `[true false]`},
+		{`m: %{ "b": 1 "a": 2 } [(keys m) (values m)]`, `[["b" "a"] [1 2]]`},
+		{`(len %{ 1: 1 2: 2 })`, "2"},
+		{`(map %{ "a": 1 } \pair -> pair.1)`, "[1: 1]"},
+		{`(eq %{ 1: 2 3: 4 } %{ 3: 4 1: 2.0 })`, "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := testEvaluationWith(tt.input)
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluationMapErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`%{ 1.5: 1 }`, "cannot use float as a map key"},
+		{`%{ [1]: 1 }`, "cannot use slice as a map key"},
+		{`%{ [1: [1]]: 1 }`, "cannot use array as a map key"},
+		{`(get { a: 1 } "a")`, "expected argument 1 to be a map, but got record"},
+		{`(put %{} \x -> x 1)`, "cannot use function as a map key"},
+	}
+
+	for _, tt := range tests {
+		_, err := testEvaluationWith(tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+// Compares looking names up by name with looking them up in the slots the
+// resolver assigns. Only the parameter is in a slot, while the function and
+// the builtins are still looked up by name, and most of the time goes to
+// allocating frames, arguments and integers, so slots gain about 10-20%.
+func BenchmarkRecursiveFibonacci(b *testing.B) {
+	input := `
+	fn fib n {
+		if (eq n 0) { 0 } else if (eq n 1) { 1 } else {
+			(add (fib (sub n 1)) (fib (sub n 2)))
+		}
+	}
+	(fib 20)
+	`
+
+	modes := []struct {
+		name string
+		opts []Option
+	}{
+		{"names", []Option{WithDynamicLookup()}},
+		{"slots", []Option{}},
+	}
+
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := testEvaluationOn(backends[0].new(object.NewEnvironment(), mode.opts...), input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
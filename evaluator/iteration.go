@@ -0,0 +1,548 @@
+package evaluator
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"raiton/object"
+)
+
+// Iteration builtins take the collection first and the function last, and
+// work on anything iterable: arrays, slices, strings, ranges and records,
+// which are iterated as pairs of field names and values. Functions can be
+// user defined or builtins. Builtins which produce collections return slices.
+
+// Returns an iterator over the argument at index i.
+func expectIterable(args []object.Object, i int) (object.Iterator, error) {
+	it, ok := object.Iterate(args[i])
+
+	if !ok {
+		return nil, Errorf(KindType, "expected argument %d to be iterable, but got %s", i+1, args[i].Type())
+	}
+
+	return it, nil
+}
+
+func expectFunction(args []object.Object, i int) (object.Object, error) {
+	if args[i].Type() != object.FUNCTION && args[i].Type() != object.BUILTIN {
+		return nil, Errorf(KindType, "expected argument %d to be a function, but got %s", i+1, args[i].Type())
+	}
+
+	return args[i], nil
+}
+
+// Checks the arguments of builtins taking a collection and a function.
+func expectIterableAndFunction(args []object.Object) (object.Iterator, object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, nil, err
+	}
+
+	it, err := expectIterable(args, 0)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fn, err := expectFunction(args, 1)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return it, fn, nil
+}
+
+// Applies the predicate to the element and returns its verdict.
+func holds(rt object.Runtime, predicate object.Object, element object.Object) (bool, error) {
+	result, err := rt.Apply(predicate, element)
+
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := result.(*object.Boolean)
+
+	if !ok {
+		return false, Errorf(KindType, "expected predicate to return a boolean, but got %s", result.Type())
+	}
+
+	return b.Value, nil
+}
+
+// Returns the elements for which the predicate holds.
+func filter(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	it, fn, err := expectIterableAndFunction(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []object.Object{}
+
+	for element, ok := it.Next(); ok; element, ok = it.Next() {
+		keep, err := holds(rt, fn, element)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if keep {
+			elements = append(elements, element)
+		}
+	}
+
+	return object.NewSlice(elements), nil
+}
+
+// Combines the elements from the left, starting with the initial value,
+// so `(fold [1 2] 0 f)` is `(f (f 0 1) 2)`.
+func fold(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 3); err != nil {
+		return nil, err
+	}
+
+	it, err := expectIterable(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := expectFunction(args, 2)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return combine(rt, it, args[1], fn)
+}
+
+// Like fold, with the first element as the initial value.
+func reduce(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	it, fn, err := expectIterableAndFunction(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	first, ok := it.Next()
+
+	if !ok {
+		return nil, fmt.Errorf("cannot reduce an empty collection")
+	}
+
+	return combine(rt, it, first, fn)
+}
+
+func combine(rt object.Runtime, it object.Iterator, acc object.Object, fn object.Object) (object.Object, error) {
+	for element, ok := it.Next(); ok; element, ok = it.Next() {
+		var err error
+
+		if acc, err = rt.Apply(fn, acc, element); err != nil {
+			return nil, err
+		}
+	}
+
+	return acc, nil
+}
+
+// Applies the function to each element for its effects, and returns the collection.
+func each(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	it, fn, err := expectIterableAndFunction(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for element, ok := it.Next(); ok; element, ok = it.Next() {
+		if _, err := rt.Apply(fn, element); err != nil {
+			return nil, err
+		}
+	}
+
+	return args[0], nil
+}
+
+// Returns tuples of the elements at the same positions in each collection,
+// as long as the shortest collection.
+func zip(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) < 2 {
+		return nil, Errorf(KindArgument, "expected at least 2 arguments, but got %d", len(args))
+	}
+
+	its := make([]object.Iterator, len(args))
+
+	for i := range args {
+		var err error
+
+		if its[i], err = expectIterable(args, i); err != nil {
+			return nil, err
+		}
+	}
+
+	elements := []object.Object{}
+
+	for {
+		group := make([]object.Object, len(its))
+
+		for i, it := range its {
+			element, ok := it.Next()
+
+			if !ok {
+				return object.NewSlice(elements), nil
+			}
+
+			group[i] = element
+		}
+
+		elements = append(elements, &object.Tuple{Elements: group})
+	}
+}
+
+// Returns pairs of the positions of the elements and the elements.
+func enumerate(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	it, err := expectIterable(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []object.Object{}
+
+	for element, ok := it.Next(); ok; element, ok = it.Next() {
+		index := &object.Integer{Value: int64(len(elements))}
+		elements = append(elements, object.MakePair(index, element))
+	}
+
+	return object.NewSlice(elements), nil
+}
+
+// Returns a range, given its end, its start and end, or its start, end and step.
+func rangefn(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) < 1 || len(args) > 3 {
+		return nil, Errorf(KindArgument, "expected 1 to 3 arguments, but got %d", len(args))
+	}
+
+	bounds := make([]int64, len(args))
+
+	for i := range args {
+		var err error
+
+		if bounds[i], err = expectInteger(args, i); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &object.Range{Step: 1}
+
+	switch len(bounds) {
+	case 1:
+		r.End = bounds[0]
+	case 2:
+		r.Start, r.End = bounds[0], bounds[1]
+	case 3:
+		r.Start, r.End, r.Step = bounds[0], bounds[1], bounds[2]
+	}
+
+	if r.Step == 0 {
+		return nil, fmt.Errorf("expected a non-zero step")
+	}
+
+	return r, nil
+}
+
+// Returns the first n elements, or all of them if there are fewer.
+func take(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	it, n, err := expectIterableAndCount(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []object.Object{}
+
+	for i := int64(0); i < n; i++ {
+		element, ok := it.Next()
+
+		if !ok {
+			break
+		}
+
+		elements = append(elements, element)
+	}
+
+	return object.NewSlice(elements), nil
+}
+
+// Returns the elements after the first n.
+func drop(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	it, n, err := expectIterableAndCount(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := int64(0); i < n; i++ {
+		if _, ok := it.Next(); !ok {
+			break
+		}
+	}
+
+	return object.NewSlice(object.Collect(it)), nil
+}
+
+func expectIterableAndCount(args []object.Object) (object.Iterator, int64, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, 0, err
+	}
+
+	it, err := expectIterable(args, 0)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	n, err := expectInteger(args, 1)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if n < 0 {
+		return nil, 0, fmt.Errorf("expected a non-negative count, but got %d", n)
+	}
+
+	return it, n, nil
+}
+
+// Returns a builtin which reports whether the predicate holds for any element
+// if want is true, or for all elements if want is false. Iteration stops at the
+// first element which decides the result.
+func quantifier(want bool) object.BuiltinFunction {
+	return func(rt object.Runtime, args ...object.Object) (object.Object, error) {
+		it, fn, err := expectIterableAndFunction(args)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for element, ok := it.Next(); ok; element, ok = it.Next() {
+			result, err := holds(rt, fn, element)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if result == want {
+				return object.BoxBoolean(want), nil
+			}
+		}
+
+		return object.BoxBoolean(!want), nil
+	}
+}
+
+// Returns the first element for which the predicate holds in a Some, or
+// None if there is none. Given a default as third argument, it returns the
+// element or the default instead.
+func find(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, Errorf(KindArgument, "expected 2 or 3 arguments, but got %d", len(args))
+	}
+
+	it, fn, err := expectIterableAndFunction(args[:2])
+
+	if err != nil {
+		return nil, err
+	}
+
+	for element, ok := it.Next(); ok; element, ok = it.Next() {
+		found, err := holds(rt, fn, element)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if found && len(args) == 3 {
+			return element, nil
+		}
+
+		if found {
+			return object.Some(element), nil
+		}
+	}
+
+	if len(args) == 3 {
+		return args[2], nil
+	}
+
+	return object.NONE, nil
+}
+
+// Maps each element to a collection, and returns the elements of all of them.
+func flatMap(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	it, fn, err := expectIterableAndFunction(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []object.Object{}
+
+	for element, ok := it.Next(); ok; element, ok = it.Next() {
+		result, err := rt.Apply(fn, element)
+
+		if err != nil {
+			return nil, err
+		}
+
+		inner, ok := object.Iterate(result)
+
+		if !ok {
+			return nil, Errorf(KindType, "expected function to return an iterable, but got %s", result.Type())
+		}
+
+		elements = append(elements, object.Collect(inner)...)
+	}
+
+	return object.NewSlice(elements), nil
+}
+
+// Returns a map of slices of the elements, keyed by the result of the
+// function, which has to be hashable. Groups are ordered by their first
+// element.
+func groupBy(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	it, fn, err := expectIterableAndFunction(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[object.HashKey][]object.Object{}
+	keys := []object.Object{}
+
+	for element, ok := it.Next(); ok; element, ok = it.Next() {
+		key, err := rt.Apply(fn, element)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hash, ok := object.Hash(key)
+
+		if !ok {
+			return nil, Errorf(KindType, "cannot group by %s keys", key.Type())
+		}
+
+		if _, ok := groups[hash]; !ok {
+			keys = append(keys, key)
+		}
+
+		groups[hash] = append(groups[hash], element)
+	}
+
+	result := object.NewMap()
+
+	for _, key := range keys {
+		hash, _ := object.Hash(key)
+
+		if err := result.Set(key, object.NewSlice(groups[hash])); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// Returns the elements in ascending order, or in the order of the
+// comparator, if given. The comparator returns either a boolean telling
+// whether its first argument goes before its second, like `lt`, or an
+// integer which is negative, zero or positive. Sorting is stable.
+func sortfn(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, Errorf(KindArgument, "expected 1 or 2 arguments, but got %d", len(args))
+	}
+
+	it, err := expectIterable(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	compare := compareObjects
+
+	if len(args) == 2 {
+		fn, err := expectFunction(args, 1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		compare = func(a, b object.Object) (int, error) {
+			return applyComparator(rt, fn, a, b)
+		}
+	}
+
+	elements := object.Collect(it)
+
+	// the first error stops the comparisons from mattering,
+	// and is reported once sorting is done
+	var failed error
+
+	slices.SortStableFunc(elements, func(a, b object.Object) int {
+		if failed != nil {
+			return 0
+		}
+
+		order, err := compare(a, b)
+
+		if err != nil {
+			failed = err
+		}
+
+		return order
+	})
+
+	if failed != nil {
+		return nil, failed
+	}
+
+	return object.NewSlice(elements), nil
+}
+
+func applyComparator(rt object.Runtime, fn object.Object, a object.Object, b object.Object) (int, error) {
+	result, err := rt.Apply(fn, a, b)
+
+	if err != nil {
+		return 0, err
+	}
+
+	switch result := result.(type) {
+	case *object.Boolean:
+		if result.Value {
+			return -1, nil
+		}
+
+		// not before does not mean after, unless the
+		// comparator says the other way round too
+		after, err := rt.Apply(fn, b, a)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if after, ok := after.(*object.Boolean); ok && after.Value {
+			return 1, nil
+		}
+
+		return 0, nil
+	case *object.Integer:
+		return cmp.Compare(result.Value, 0), nil
+	default:
+		return 0, Errorf(KindType, "expected comparator to return a boolean or an integer, but got %s", result.Type())
+	}
+}
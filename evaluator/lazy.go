@@ -0,0 +1,124 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"raiton/ast"
+	"raiton/object"
+)
+
+// Defers the evaluation of the expression in the current environment.
+func (e *Evaluator) delay(expr ast.Expression) object.Object {
+	return &object.Thunk{
+		Expression:  expr,
+		Environment: e.env,
+	}
+}
+
+// Evaluates the expression, deferring its evaluation in lazy mode.
+// Literals are evaluated right away, since doing so has no effects.
+func (e *Evaluator) evaluateDelayed(expr ast.Expression) (object.Object, error) {
+	if e.config.Lazy && !IsLiteral(expr) {
+		return e.delay(expr), nil
+	}
+
+	if err := expr.Accept(e); err != nil {
+		return nil, err
+	}
+
+	return e.results.pop(), nil
+}
+
+// Returns the value of the object, evaluating it first if it is a thunk
+// that hasn't been forced yet. Forcing a thunk counts as a function call
+// towards the call depth limit. A thunk may evaluate to another thunk,
+// which is forced in turn in a loop, so chains of them don't grow the stack.
+func (e *Evaluator) force(obj object.Object) (object.Object, error) {
+	if _, ok := obj.(*object.Thunk); !ok {
+		return obj, nil
+	}
+
+	var chain []*object.Thunk
+
+	defer func() {
+		for _, thunk := range chain {
+			thunk.Forcing = false
+		}
+	}()
+
+	for {
+		thunk, ok := obj.(*object.Thunk)
+
+		if !ok {
+			break
+		}
+
+		if thunk.Value != nil {
+			obj = thunk.Value
+			break
+		}
+
+		if thunk.Forcing {
+			return nil, fmt.Errorf("lazy value `%s` depends on itself", ast.NewPrinter(thunk.Expression).String())
+		}
+
+		thunk.Forcing = true
+		chain = append(chain, thunk)
+
+		value, err := e.evaluateThunk(thunk)
+
+		if err != nil {
+			return nil, err
+		}
+
+		obj = value
+	}
+
+	for _, thunk := range chain {
+		thunk.Value = obj
+		// the environment is not needed anymore, so don't keep it alive
+		thunk.Expression = nil
+		thunk.Environment = nil
+	}
+
+	return obj, nil
+}
+
+// Evaluates the expression of the thunk in its environment, without
+// forcing the result.
+func (e *Evaluator) evaluateThunk(thunk *object.Thunk) (object.Object, error) {
+	if err := e.meter.Enter(); err != nil {
+		return nil, err
+	}
+
+	enclosing := e.env
+	e.env = thunk.Environment
+
+	defer func() {
+		e.env = enclosing
+		e.meter.Leave()
+	}()
+
+	if err := thunk.Expression.Accept(e); err != nil {
+		return nil, err
+	}
+
+	return e.results.pop(), nil
+}
+
+// Pops the result of the last evaluation and forces it.
+func (e *Evaluator) popForced() (object.Object, error) {
+	return e.force(e.results.pop())
+}
+
+// Reports whether evaluating the expression has no effects,
+// so there is no point in delaying it.
+func IsLiteral(expr ast.Expression) bool {
+	switch expr.(type) {
+	case *ast.FunctionLiteral, *ast.Lazy, *ast.IntegerLiteral, *ast.BigIntegerLiteral, *ast.FloatLiteral,
+		*ast.StringLiteral, *ast.CharacterLiteral, *ast.BooleanLiteral:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,34 @@
+package evaluator
+
+import (
+	"errors"
+
+	"raiton/object"
+)
+
+// Runs the body of a loop for each element of the collection, in order.
+// The body stops the loop by returning ErrBreak, and goes on with the
+// next element by returning ErrContinue. Loops evaluate to the collection
+// they iterate over.
+func Loop(collection object.Object, body func(element object.Object) error) (object.Object, error) {
+	it, ok := object.Iterate(collection)
+
+	if !ok {
+		return nil, Errorf(KindType, "expected a collection to loop over, but got %s", collection.Type())
+	}
+
+	for element, ok := it.Next(); ok; element, ok = it.Next() {
+		err := body(element)
+
+		switch {
+		case errors.Is(err, ErrBreak):
+			return collection, nil
+		case errors.Is(err, ErrContinue):
+			continue
+		case err != nil:
+			return nil, err
+		}
+	}
+
+	return collection, nil
+}
@@ -0,0 +1,126 @@
+package evaluator
+
+import "raiton/object"
+
+func expectMap(args []object.Object, i int) (*object.Map, error) {
+	m, ok := args[i].(*object.Map)
+
+	if !ok {
+		return nil, Errorf(KindType, "expected argument %d to be a map, but got %s", i+1, args[i].Type())
+	}
+
+	return m, nil
+}
+
+// Returns the value bound to the key in a Some, or None if there is none.
+// Given a default as third argument, it returns the value or the default
+// instead. Applied to a reference alone, it returns the value it holds.
+func get(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) == 1 {
+		return deref(rt, args...)
+	}
+
+	if len(args) != 2 && len(args) != 3 {
+		return nil, Errorf(KindArgument, "expected 1 to 3 arguments, but got %d", len(args))
+	}
+
+	m, err := expectMap(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok, err := m.Get(args[1])
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 3 {
+		if ok {
+			return value, nil
+		}
+
+		return args[2], nil
+	}
+
+	if ok {
+		return object.Some(value), nil
+	}
+
+	return object.NONE, nil
+}
+
+// Returns a copy of the map with the key bound to the value.
+func put(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 3); err != nil {
+		return nil, err
+	}
+
+	m, err := expectMap(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Put(args[1], args[2])
+}
+
+// Returns a copy of the map without the key.
+func deletefn(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
+	}
+
+	m, err := expectMap(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Delete(args[1])
+}
+
+func has(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
+	}
+
+	m, err := expectMap(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, ok, err := m.Get(args[1])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return object.BoxBoolean(ok), nil
+}
+
+// Returns a builtin which returns a slice of the part of each entry
+// picked by part, in the order of the entries.
+func entries(part func(entry *object.MapEntry) object.Object) object.BuiltinFunction {
+	return func(_ object.Runtime, args ...object.Object) (object.Object, error) {
+		if err := expectArguments(args, 1); err != nil {
+			return nil, err
+		}
+
+		m, err := expectMap(args, 0)
+
+		if err != nil {
+			return nil, err
+		}
+
+		elements := []object.Object{}
+
+		for _, entry := range m.Entries() {
+			elements = append(elements, part(entry))
+		}
+
+		return object.NewSlice(elements), nil
+	}
+}
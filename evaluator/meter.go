@@ -0,0 +1,77 @@
+package evaluator
+
+import (
+	"context"
+	"time"
+)
+
+// Meter keeps track of the steps and the call depth of an evaluation,
+// and fails once a limit is exceeded or the evaluation is canceled.
+type Meter struct {
+	maxDepth int
+	maxSteps int
+	timeout  time.Duration
+	depth    int
+	steps    int
+	done     <-chan struct{}
+	ctx      context.Context
+}
+
+func NewMeter(config Config) Meter {
+	return Meter{
+		maxDepth: config.MaxDepth,
+		maxSteps: config.MaxSteps,
+		timeout:  config.Timeout,
+	}
+}
+
+// Starts metering an evaluation done within the context. The returned
+// context is done once the timeout expires, and has to be canceled when
+// the evaluation completes.
+func (m *Meter) Start(ctx context.Context) (context.Context, context.CancelFunc) {
+	var cancel context.CancelFunc
+
+	if m.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	m.ctx = ctx
+	m.done = ctx.Done()
+	m.steps = 0
+
+	return ctx, cancel
+}
+
+// Counts an evaluation step.
+func (m *Meter) Step() error {
+	m.steps += 1
+
+	if m.maxSteps > 0 && m.steps > m.maxSteps {
+		return stepLimitError(m.maxSteps)
+	}
+
+	select {
+	case <-m.done:
+		return contextError(m.ctx.Err())
+	default:
+		return nil
+	}
+}
+
+// Enters a function call, or the forcing of a thunk.
+func (m *Meter) Enter() error {
+	if m.maxDepth > 0 && m.depth >= m.maxDepth {
+		return depthLimitError(m.maxDepth)
+	}
+
+	m.depth += 1
+
+	return nil
+}
+
+// Leaves the call entered last.
+func (m *Meter) Leave() {
+	m.depth -= 1
+}
@@ -0,0 +1,100 @@
+package evaluator
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"raiton/object"
+)
+
+// Config holds the settings of an evaluation,
+// shared by the evaluator and other backends.
+type Config struct {
+	Builtins map[string]object.Object
+	Stdout   io.Writer
+	Stderr   io.Writer
+	MaxDepth int
+	MaxSteps int
+	Timeout  time.Duration
+	Lazy     bool
+	Dynamic  bool
+}
+
+type Option func(c *Config)
+
+// Creates a configuration with the default builtins and
+// standard streams, and without limits, modified by the options.
+func NewConfig(opts ...Option) Config {
+	c := Config{
+		Builtins: defaultBuiltins,
+		Stdout:   os.Stdout,
+		Stderr:   os.Stderr,
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
+}
+
+// Replaces the default builtins with the given set. Builtins are
+// looked up after the environment, so definitions shadow them.
+func WithBuiltins(builtins map[string]object.Object) Option {
+	return func(c *Config) {
+		c.Builtins = builtins
+	}
+}
+
+// Sets the writer the program's standard output goes to.
+func WithStdout(w io.Writer) Option {
+	return func(c *Config) {
+		c.Stdout = w
+	}
+}
+
+// Sets the writer the program's standard error goes to.
+func WithStderr(w io.Writer) Option {
+	return func(c *Config) {
+		c.Stderr = w
+	}
+}
+
+// Limits the depth of nested function calls. Zero means no limit.
+func WithMaxDepth(depth int) Option {
+	return func(c *Config) {
+		c.MaxDepth = depth
+	}
+}
+
+// Limits the number of steps, that is scope items and applications,
+// an evaluation can take. Zero means no limit.
+func WithMaxSteps(steps int) Option {
+	return func(c *Config) {
+		c.MaxSteps = steps
+	}
+}
+
+// Limits the wall-clock time an evaluation can take. Zero means no limit.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.Timeout = timeout
+	}
+}
+
+// Evaluates definitions and arguments of function applications
+// only when their value is first needed.
+func WithLazyEvaluation() Option {
+	return func(c *Config) {
+		c.Lazy = true
+	}
+}
+
+// Looks identifiers up by name in the chain of environments,
+// instead of resolving them to slots before evaluation.
+func WithDynamicLookup() Option {
+	return func(c *Config) {
+		c.Dynamic = true
+	}
+}
@@ -0,0 +1,46 @@
+package evaluator
+
+import "raiton/object"
+
+// Raises an error. It is given either a message, a kind and a message,
+// optionally followed by a payload, or an error which was caught before,
+// to raise it again.
+func raise(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) == 1 {
+		if raised, ok := args[0].(*object.Error); ok {
+			return nil, raised
+		}
+
+		message, err := expectString(args, 0)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, object.NewError(KindError, message, nil)
+	}
+
+	if len(args) != 2 && len(args) != 3 {
+		return nil, Errorf(KindArgument, "expected 1 to 3 arguments, but got %d", len(args))
+	}
+
+	kind, err := expectString(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := expectString(args, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var payload object.Object
+
+	if len(args) == 3 {
+		payload = args[2]
+	}
+
+	return nil, object.NewError(kind, message, payload)
+}
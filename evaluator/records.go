@@ -0,0 +1,215 @@
+package evaluator
+
+import (
+	"raiton/ast"
+	"raiton/object"
+)
+
+// Builds the record of a literal from the values of its base, which is nil
+// unless the literal is an update, and of its fields. The fields of an
+// update have to be defined on its base, so a misspelled field is an error
+// instead of a new field. Spreads copy the fields of their records, and
+// fields defined again later keep their place, but take the later value.
+func BuildRecord(base object.Object, fields []*ast.RecordField, values []object.Object) (*object.Record, error) {
+	record := object.NewRecord()
+
+	if base != nil {
+		b, ok := base.(*object.Record)
+
+		if !ok {
+			return nil, Errorf(KindType, "expected a record to update, but got %s", base.Type())
+		}
+
+		record = b.Copy()
+	}
+
+	for i, field := range fields {
+		if field.Spread {
+			spread, ok := values[i].(*object.Record)
+
+			if !ok {
+				return nil, Errorf(KindType, "cannot spread %s into a record", values[i].Type())
+			}
+
+			for _, name := range spread.Names() {
+				value, _ := spread.Get(name)
+				record.Set(name, value)
+			}
+
+			continue
+		}
+
+		name := string(field.Identifier)
+
+		if _, ok := record.Get(name); base != nil && !ok {
+			return nil, Errorf(KindField, "cannot update field '%s', which is not defined on the record", name)
+		}
+
+		record.Set(name, values[i])
+	}
+
+	return record, nil
+}
+
+func expectRecord(args []object.Object, i int) (*object.Record, error) {
+	record, ok := args[i].(*object.Record)
+
+	if !ok {
+		return nil, Errorf(KindType, "expected argument %d to be a record, but got %s", i+1, args[i].Type())
+	}
+
+	return record, nil
+}
+
+// Returns a record with the fields of all the records, where fields of
+// later records replace those of earlier ones.
+func merge(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) == 0 {
+		return nil, Errorf(KindArgument, "expected at least 1 argument, but got 0")
+	}
+
+	merged := object.NewRecord()
+
+	for i := range args {
+		record, err := expectRecord(args, i)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, field := range record.Names() {
+			value, _ := record.Get(field)
+			merged.Set(field, value)
+		}
+	}
+
+	return merged, nil
+}
+
+// Returns a slice of the names of the fields, in order.
+func fields(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	record, err := expectRecord(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return makeStrings(record.Names()), nil
+}
+
+func hasField(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
+	}
+
+	record, err := expectRecord(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	field, err := expectString(args, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, ok := record.Get(field)
+
+	return object.BoxBoolean(ok), nil
+}
+
+// Returns a copy of the record without the field, which has to be defined.
+func removeField(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
+	}
+
+	record, err := expectRecord(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	field, err := expectString(args, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := record.Get(field); !ok {
+		return nil, Errorf(KindField, "field '%s' not defined on record", field)
+	}
+
+	removed := object.NewRecord()
+
+	for _, name := range record.Names() {
+		if value, _ := record.Get(name); name != field {
+			removed.Set(name, value)
+		}
+	}
+
+	return removed, nil
+}
+
+// Returns a slice of pairs of the names and values of the fields, in order.
+func toPairs(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	record, err := expectRecord(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return object.NewSlice(object.Collect(record.Iterate())), nil
+}
+
+// Returns a record of a collection of pairs of names and values, as made
+// by to_pairs. Names given more than once take the last value.
+func fromPairs(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	it, err := expectIterable(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	record := object.NewRecord()
+
+	for i, element := range object.Collect(it) {
+		var pair []object.Object
+
+		switch element := element.(type) {
+		case *object.Tuple:
+			pair = element.Elements
+		case *object.Array:
+			pair = element.Value
+		case *object.Slice:
+			pair = element.Elements()
+		}
+
+		if len(pair) != 2 {
+			return nil, Errorf(KindType, "expected element %d to be a pair, but got %s", i, element.Inspect())
+		}
+
+		field, err := expectString(pair, 0)
+
+		if err != nil {
+			return nil, Errorf(KindType, "expected the name of pair %d to be a string, but got %s", i, pair[0].Type())
+		}
+
+		record.Set(field, pair[1])
+	}
+
+	return record, nil
+}
@@ -0,0 +1,60 @@
+package evaluator
+
+import "raiton/object"
+
+func expectRef(args []object.Object, i int) (*object.Ref, error) {
+	r, ok := args[i].(*object.Ref)
+
+	if !ok {
+		return nil, Errorf(KindType, "expected argument %d to be a reference, but got %s", i+1, args[i].Type())
+	}
+
+	return r, nil
+}
+
+// Returns a new reference holding the value.
+func ref(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	return &object.Ref{Value: args[0]}, nil
+}
+
+// Returns the value the reference holds.
+func deref(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	r, err := expectRef(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Value, nil
+}
+
+// Assigns the value to the reference like `set!` does, for `target := value`,
+// which does not depend on what the name `set!` is bound to.
+func Assign(target, value object.Object) (object.Object, error) {
+	return set(nil, target, value)
+}
+
+// Replaces the value the reference holds, and returns the new value.
+func set(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
+	}
+
+	r, err := expectRef(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.Value = args[1]
+
+	return r.Value, nil
+}
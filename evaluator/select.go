@@ -0,0 +1,191 @@
+package evaluator
+
+import (
+	"unicode/utf8"
+
+	"raiton/ast"
+	"raiton/object"
+)
+
+// Returns the key of a selector item which is not computed, which
+// is the name of a field as a string, or an index as an integer.
+func ItemKey(i *ast.SelectorItem) object.Object {
+	if i.Identifier != nil {
+		return &object.String{Value: string(*i.Identifier)}
+	}
+
+	return &object.Integer{Value: int64(*i.Index)}
+}
+
+// Selects the field, element or character the key refers to from the
+// object. Records and errors are selected from by field names, maps by
+// their keys, and arrays, slices, tuples and strings by indexes, which
+// count from the end if they are negative.
+func Select(obj object.Object, key object.Object) (object.Object, error) {
+	switch obj := obj.(type) {
+	case *object.Record:
+		name, ok := key.(*object.String)
+
+		if !ok {
+			return nil, Errorf(KindType, "can only access record fields with names, but got %s", key.Type())
+		}
+
+		value, ok := obj.Get(name.Value)
+
+		if !ok {
+			return nil, Errorf(KindField, "field '%s' not defined on record", name.Value)
+		}
+
+		return value, nil
+	case *object.Error:
+		name, ok := key.(*object.String)
+
+		if !ok {
+			return nil, Errorf(KindType, "can only access error fields with names, but got %s", key.Type())
+		}
+
+		switch name.Value {
+		case "kind":
+			return &object.String{Value: obj.Kind}, nil
+		case "message":
+			return &object.String{Value: obj.Message}, nil
+		case "payload":
+			return obj.Payload, nil
+		default:
+			return nil, Errorf(KindField, "field '%s' not defined on error", name.Value)
+		}
+	case *object.Map:
+		value, ok, err := obj.Get(key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, Errorf(KindKey, "key %s not found in map", key.Inspect())
+		}
+
+		return value, nil
+	case *object.Array:
+		return selectElement(obj.Value, key)
+	case *object.Slice:
+		return selectElement(obj.Elements(), key)
+	case *object.Tuple:
+		return selectElement(obj.Elements, key)
+	case *object.String:
+		runes := []rune(obj.Value)
+		index, err := selectIndex(key, len(runes))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &object.Character{Value: string(runes[index])}, nil
+	default:
+		return nil, Errorf(KindType, "expected a collection but got %s", obj.Type())
+	}
+}
+
+// Selects the elements or characters from the start index up to, but not
+// including, the end index. Ranges of arrays and slices are slices which
+// share their elements, and ranges of strings are strings.
+func SelectRange(obj object.Object, start object.Object, end object.Object) (object.Object, error) {
+	var length int
+
+	switch obj := obj.(type) {
+	case *object.Array:
+		length = len(obj.Value)
+	case *object.Slice:
+		length = int(obj.Length)
+	case *object.String:
+		length = utf8.RuneCountInString(obj.Value)
+	default:
+		return nil, Errorf(KindType, "expected an array, a slice or a string to select a range of, but got %s", obj.Type())
+	}
+
+	for _, bound := range []object.Object{start, end} {
+		if bound.Type() != object.INTEGER {
+			return nil, Errorf(KindType, "can only select ranges with indexes, but got %s", bound.Type())
+		}
+	}
+
+	low, okLow := boundIndex(start, length)
+	high, okHigh := boundIndex(end, length)
+
+	if !okLow || !okHigh || low > high {
+		return nil, Errorf(KindIndex, "range %s..%s is out of bounds for length %d", start.Inspect(), end.Inspect(), length)
+	}
+
+	switch obj := obj.(type) {
+	case *object.Array:
+		return object.SliceOf(obj).Slice(uint64(low), uint64(high))
+	case *object.Slice:
+		return obj.Slice(uint64(low), uint64(high))
+	default:
+		runes := []rune(obj.(*object.String).Value)
+		return &object.String{Value: string(runes[low:high])}, nil
+	}
+}
+
+func selectElement(elements []object.Object, key object.Object) (object.Object, error) {
+	index, err := selectIndex(key, len(elements))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return elements[index], nil
+}
+
+// Returns the position the index refers to in a sequence of the length.
+func selectIndex(key object.Object, length int) (int, error) {
+	if key.Type() != object.INTEGER {
+		return 0, Errorf(KindType, "can only access elements with indexes, but got %s", key.Type())
+	}
+
+	index, ok := boundIndex(key, length)
+
+	if !ok || index == length {
+		return 0, Errorf(KindIndex, "index %s is out of bounds for length %d", key.Inspect(), length)
+	}
+
+	return index, nil
+}
+
+// Returns the position the integer refers to in a sequence of the length,
+// which may be the length itself, as the end of a range can be.
+func boundIndex(key object.Object, length int) (int, bool) {
+	integer, ok := key.(*object.Integer)
+
+	if !ok {
+		return 0, false
+	}
+
+	index := integer.Value
+
+	if index < 0 {
+		index += int64(length)
+	}
+
+	if index < 0 || index > int64(length) {
+		return 0, false
+	}
+
+	return int(index), true
+}
+
+// Returns the element at the index of a tuple which is destructured into
+// as many names as it has elements.
+func Unpack(obj object.Object, index int, size int) (object.Object, error) {
+	tuple, ok := obj.(*object.Tuple)
+
+	if !ok {
+		return nil, Errorf(KindType, "cannot destructure %s into a tuple of %d elements", obj.Type(), size)
+	}
+
+	if len(tuple.Elements) != size {
+		return nil, Errorf(KindType, "cannot destructure a tuple of %d elements into %d names", len(tuple.Elements), size)
+	}
+
+	return tuple.Elements[index], nil
+}
@@ -0,0 +1,125 @@
+package evaluator
+
+import (
+	"math/big"
+	"unicode/utf8"
+
+	"raiton/object"
+)
+
+// Returns a view of the elements of an array or slice from the low index
+// up to, but not including, the high index, which defaults to the length.
+// Negative indexes count from the end, like in range selectors, and the
+// high index may go past the length up to the capacity. The view shares
+// the elements of the collection it is taken from.
+func slice(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, Errorf(KindArgument, "expected 2 or 3 arguments, but got %d", len(args))
+	}
+
+	s, err := expectSlice(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := []int64{0, int64(s.Length)}
+
+	for i := 1; i < len(args); i++ {
+		bound, err := expectInteger(args, i)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if bound < 0 {
+			if bound += int64(s.Length); bound < 0 {
+				return nil, Errorf(KindIndex, "index %s is out of bounds for length %d", args[i].Inspect(), s.Length)
+			}
+		}
+
+		bounds[i-1] = bound
+	}
+
+	low, high := bounds[0], bounds[1]
+
+	if low > high || uint64(high) > s.Cap() {
+		return nil, Errorf(KindIndex, "slice bounds %d..%d are out of range for capacity %d", low, high, s.Cap())
+	}
+
+	return s.Slice(uint64(low), uint64(high))
+}
+
+// Returns a view of the argument at index i, which has to be an array or a slice.
+func expectSlice(args []object.Object, i int) (*object.Slice, error) {
+	switch arg := args[i].(type) {
+	case *object.Array:
+		return object.SliceOf(arg), nil
+	case *object.Slice:
+		return arg, nil
+	default:
+		return nil, Errorf(KindType, "expected argument %d to be an array or a slice, but got %s", i+1, arg.Type())
+	}
+}
+
+// Returns a slice with the elements added to the end of the array or slice.
+func appendfn(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if len(args) < 1 {
+		return nil, Errorf(KindArgument, "expected at least 1 argument, but got 0")
+	}
+
+	s, err := expectSlice(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Append(args[1:]...), nil
+}
+
+// Returns the number of elements of a collection, which for
+// strings is the number of characters, for records of fields, and
+// for maps of entries.
+func length(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	var n uint64
+
+	switch arg := args[0].(type) {
+	case *object.Array:
+		n = uint64(len(arg.Value))
+	case *object.Slice:
+		n = arg.Length
+	case *object.Tuple:
+		n = uint64(len(arg.Elements))
+	case *object.String:
+		n = uint64(utf8.RuneCountInString(arg.Value))
+	case *object.Record:
+		n = uint64(arg.Len())
+	case *object.Map:
+		n = uint64(arg.Len())
+	case *object.Range:
+		n = arg.Len()
+	default:
+		return nil, Errorf(KindType, "expected argument 1 to be a collection, but got %s", arg.Type())
+	}
+
+	return object.MakeInteger(new(big.Int).SetUint64(n)), nil
+}
+
+// Returns the capacity of a slice, or the size of an array.
+func capacity(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	s, err := expectSlice(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &object.Integer{Value: int64(s.Cap())}, nil
+}
@@ -28,3 +28,13 @@ func (s *stack) pop() object.Object {
 	s.values = s.values[:l-1]
 	return obj
 }
+
+func (s *stack) height() int {
+	return len(s.values)
+}
+
+// Drops the values above the height, which an evaluation
+// stopped by a break or a continue may leave behind.
+func (s *stack) truncate(height int) {
+	s.values = s.values[:height]
+}
@@ -0,0 +1,465 @@
+package evaluator
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"raiton/object"
+)
+
+// The string module, bound to `str` by default, so its functions are called
+// like `(str.split "a,b" ",")`. Strings are sequences of runes: lengths and
+// positions are counted in runes, not in bytes. Characters are accepted
+// wherever a string is.
+var stringFunctions = map[string]object.BuiltinFunction{
+	"len":         strLen,
+	"split":       split,
+	"join":        join,
+	"trim":        trim,
+	"replace":     replace,
+	"contains":    stringPredicate(strings.Contains),
+	"starts_with": stringPredicate(strings.HasPrefix),
+	"ends_with":   stringPredicate(strings.HasSuffix),
+	"upper":       stringMapping(strings.ToUpper),
+	"lower":       stringMapping(strings.ToLower),
+	"substring":   substring,
+	"index_of":    indexOf,
+	"repeat":      repeat,
+	"chars":       chars,
+	"from_chars":  fromChars,
+	"to_number":   toNumber,
+	"from_number": fromNumber,
+}
+
+// Returns a record of pure builtins, used as a namespace.
+func namespace(fns map[string]object.BuiltinFunction) *object.Record {
+	record := object.NewRecord()
+	names := make([]string, 0, len(fns))
+
+	for name := range fns {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		record.Set(name, object.MakePureBuiltin(fns[name]))
+	}
+
+	return record
+}
+
+func expectArguments(args []object.Object, n int) error {
+	if len(args) == n {
+		return nil
+	}
+
+	if n == 1 {
+		return Errorf(KindArgument, "expected one argument, but got %d", len(args))
+	}
+
+	return Errorf(KindArgument, "expected %d arguments, but got %d", n, len(args))
+}
+
+// Returns the text of the argument at index i, which has to be a string or a character.
+func expectString(args []object.Object, i int) (string, error) {
+	switch arg := args[i].(type) {
+	case *object.String:
+		return arg.Value, nil
+	case *object.Character:
+		return arg.Value, nil
+	default:
+		return "", Errorf(KindType, "expected argument %d to be a string, but got %s", i+1, arg.Type())
+	}
+}
+
+func expectStrings(args []object.Object) ([]string, error) {
+	strs := make([]string, len(args))
+
+	for i := range args {
+		str, err := expectString(args, i)
+
+		if err != nil {
+			return nil, err
+		}
+
+		strs[i] = str
+	}
+
+	return strs, nil
+}
+
+// Returns the value of the argument at index i, which has to be an integer.
+func expectInteger(args []object.Object, i int) (int64, error) {
+	switch arg := args[i].(type) {
+	case *object.Integer:
+		return arg.Value, nil
+	case *object.BigInt:
+		return 0, fmt.Errorf("argument %d is too large", i+1)
+	default:
+		return 0, Errorf(KindType, "expected argument %d to be an integer, but got %s", i+1, arg.Type())
+	}
+}
+
+func makeStrings(strs []string) *object.Slice {
+	elements := make([]object.Object, len(strs))
+
+	for i, str := range strs {
+		elements[i] = &object.String{Value: str}
+	}
+
+	return object.NewSlice(elements)
+}
+
+// Returns the text of the object as it is printed, which is the
+// contents of strings and characters, and the inspection of others.
+func display(obj object.Object) string {
+	switch obj := obj.(type) {
+	case *object.String:
+		return obj.Value
+	case *object.Character:
+		return obj.Value
+	default:
+		return obj.Inspect()
+	}
+}
+
+func concat(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	strs, err := expectStrings(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &object.String{Value: strings.Join(strs, "")}, nil
+}
+
+func printfn(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	return write(rt, "", args)
+}
+
+func printlnfn(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	return write(rt, "\n", args)
+}
+
+// Writes the arguments separated by spaces to the standard output,
+// and returns the last one, so printing can wrap any expression.
+func write(rt object.Runtime, end string, args []object.Object) (object.Object, error) {
+	if len(args) == 0 {
+		return nil, Errorf(KindArgument, "expected at least 1 argument, but got 0")
+	}
+
+	strs := make([]string, len(args))
+
+	for i, arg := range args {
+		strs[i] = display(arg)
+	}
+
+	if _, err := fmt.Fprint(rt.Stdout(), strings.Join(strs, " ")+end); err != nil {
+		return nil, err
+	}
+
+	return args[len(args)-1], nil
+}
+
+func strLen(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	str, err := expectString(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &object.Integer{Value: int64(utf8.RuneCountInString(str))}, nil
+}
+
+// Splits the string around each occurrence of the separator,
+// or into strings of one rune each if the separator is empty.
+func split(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
+	}
+
+	strs, err := expectStrings(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return makeStrings(strings.Split(strs[0], strs[1])), nil
+}
+
+func join(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
+	}
+
+	it, err := expectIterable(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	elements := object.Collect(it)
+	sep, err := expectString(args, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	strs := make([]string, len(elements))
+
+	for i, element := range elements {
+		if strs[i], err = expectString(elements, i); err != nil {
+			return nil, Errorf(KindType, "expected element %d to be a string, but got %s", i, element.Type())
+		}
+	}
+
+	return &object.String{Value: strings.Join(strs, sep)}, nil
+}
+
+// Trims white space from both ends of the string.
+func trim(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	str, err := expectString(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &object.String{Value: strings.TrimSpace(str)}, nil
+}
+
+// Replaces all occurrences of the second string with the third.
+func replace(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 3); err != nil {
+		return nil, err
+	}
+
+	strs, err := expectStrings(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &object.String{Value: strings.ReplaceAll(strs[0], strs[1], strs[2])}, nil
+}
+
+func stringPredicate(predicate func(s, substr string) bool) object.BuiltinFunction {
+	return func(_ object.Runtime, args ...object.Object) (object.Object, error) {
+		if err := expectArguments(args, 2); err != nil {
+			return nil, err
+		}
+
+		strs, err := expectStrings(args)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return object.BoxBoolean(predicate(strs[0], strs[1])), nil
+	}
+}
+
+func stringMapping(mapping func(s string) string) object.BuiltinFunction {
+	return func(_ object.Runtime, args ...object.Object) (object.Object, error) {
+		if err := expectArguments(args, 1); err != nil {
+			return nil, err
+		}
+
+		str, err := expectString(args, 0)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &object.String{Value: mapping(str)}, nil
+	}
+}
+
+// Returns the runes from the start position up to, but not including, the end position.
+func substring(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 3); err != nil {
+		return nil, err
+	}
+
+	str, err := expectString(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := expectInteger(args, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := expectInteger(args, 2)
+
+	if err != nil {
+		return nil, err
+	}
+
+	runes := []rune(str)
+
+	if start < 0 || end < start || end > int64(len(runes)) {
+		return nil, Errorf(KindIndex, "range %d..%d is out of bounds for a string of length %d", start, end, len(runes))
+	}
+
+	return &object.String{Value: string(runes[start:end])}, nil
+}
+
+// Returns the position of the first occurrence of the second string, or -1 if there is none.
+func indexOf(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
+	}
+
+	strs, err := expectStrings(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	index := strings.Index(strs[0], strs[1])
+
+	if index >= 0 {
+		index = utf8.RuneCountInString(strs[0][:index])
+	}
+
+	return &object.Integer{Value: int64(index)}, nil
+}
+
+// The length of the longest string repeat builds, so a large count is an
+// error instead of running out of memory.
+const maxRepeatLength = 1 << 28
+
+func repeat(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
+	}
+
+	str, err := expectString(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := expectInteger(args, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if count < 0 {
+		return nil, Errorf(KindValue, "expected a non-negative count, but got %d", count)
+	}
+
+	// divided rather than multiplied, so the length can't overflow
+	if len(str) > 0 && count > int64(maxRepeatLength/len(str)) {
+		return nil, Errorf(KindValue, "repeating a string of length %d %d times exceeds the maximum length of %d", len(str), count, maxRepeatLength)
+	}
+
+	return &object.String{Value: strings.Repeat(str, int(count))}, nil
+}
+
+// Returns a slice of the characters of the string.
+func chars(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	str, err := expectString(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []object.Object{}
+
+	for _, r := range str {
+		elements = append(elements, &object.Character{Value: string(r)})
+	}
+
+	return object.NewSlice(elements), nil
+}
+
+// Returns the string made of a collection of characters.
+func fromChars(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	it, err := expectIterable(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	elements := object.Collect(it)
+
+	var sb strings.Builder
+
+	for i, element := range elements {
+		c, ok := element.(*object.Character)
+
+		if !ok {
+			return nil, Errorf(KindType, "expected element %d to be a character, but got %s", i, element.Type())
+		}
+
+		sb.WriteString(c.Value)
+	}
+
+	return &object.String{Value: sb.String()}, nil
+}
+
+// Parses a decimal integer or float, written as it would be in a program,
+// and returns it in an Ok, or an error of the kind value in an Err, with
+// the string as the input field of its payload.
+func toNumber(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	str, err := expectString(args, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if value, ok := new(big.Int).SetString(str, 10); ok {
+		return object.Ok(object.MakeInteger(value)), nil
+	}
+
+	if value, err := strconv.ParseFloat(str, 64); err == nil {
+		return object.Ok(&object.Float{Value: value}), nil
+	}
+
+	payload := object.NewRecord()
+	payload.Set("input", &object.String{Value: str})
+
+	return object.Err(object.NewError(KindValue, fmt.Sprintf("cannot convert %q to a number", str), payload)), nil
+}
+
+func fromNumber(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	if !isNumber(args[0]) {
+		return nil, Errorf(KindType, "expected argument 1 to be a number, but got %s", args[0].Type())
+	}
+
+	return &object.String{Value: args[0].Inspect()}, nil
+}
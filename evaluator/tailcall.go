@@ -0,0 +1,14 @@
+package evaluator
+
+import "raiton/object"
+
+// A function application in tail position. It is returned to the
+// application of the enclosing function, which applies it in its place.
+type TailCall struct {
+	Function  *object.Function
+	Arguments []object.Object
+}
+
+func (t *TailCall) Type() object.ObjectType { return "tail_call" }
+
+func (t *TailCall) Inspect() string { return "tail call" }
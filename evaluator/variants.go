@@ -0,0 +1,176 @@
+package evaluator
+
+import "raiton/object"
+
+func expectVariant(args []object.Object, i int) (object.Object, error) {
+	switch args[i].(type) {
+	case *object.Result, *object.Option:
+		return args[i], nil
+	default:
+		return nil, Errorf(KindType, "expected argument %d to be a result or an option, but got %s", i+1, args[i].Type())
+	}
+}
+
+// Returns the value inside an Ok or a Some, and false for an Err or None.
+func unwrapped(variant object.Object) (object.Object, bool) {
+	switch variant := variant.(type) {
+	case *object.Result:
+		return variant.Value, variant.Ok
+	case *object.Option:
+		return variant.Value, variant.Value != nil
+	}
+
+	return nil, false
+}
+
+// Returns a builtin wrapping its argument in a variant.
+func variant(wrap func(object.Object) object.Object) object.BuiltinFunction {
+	return func(_ object.Runtime, args ...object.Object) (object.Object, error) {
+		if err := expectArguments(args, 1); err != nil {
+			return nil, err
+		}
+
+		return wrap(args[0]), nil
+	}
+}
+
+// Applies the function to the value inside an Ok or a Some, and wraps the
+// result the same way. An Err or None is returned as it is.
+func mapVariant(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	fn, err := expectFunction(args, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := unwrapped(args[0])
+
+	if !ok {
+		return args[0], nil
+	}
+
+	result, err := rt.Apply(fn, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, isResult := args[0].(*object.Result); isResult {
+		return object.Ok(result), nil
+	}
+
+	return object.Some(result), nil
+}
+
+// Applies the function, which returns a variant of the same type, to the
+// value inside an Ok or a Some. An Err or None is returned as it is.
+func andThen(rt object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
+	}
+
+	if _, err := expectVariant(args, 0); err != nil {
+		return nil, err
+	}
+
+	fn, err := expectFunction(args, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := unwrapped(args[0])
+
+	if !ok {
+		return args[0], nil
+	}
+
+	result, err := rt.Apply(fn, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Type() != args[0].Type() {
+		return nil, Errorf(KindType, "expected function to return a %s, but got %s", args[0].Type(), result.Type())
+	}
+
+	return result, nil
+}
+
+// Returns the value inside an Ok or a Some, or the default for an Err or None.
+func unwrapOr(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 2); err != nil {
+		return nil, err
+	}
+
+	if _, err := expectVariant(args, 0); err != nil {
+		return nil, err
+	}
+
+	if value, ok := unwrapped(args[0]); ok {
+		return value, nil
+	}
+
+	return args[1], nil
+}
+
+// Returns the value inside an Ok or a Some. An Err holding an error raises
+// it, and any other Err or None raises an error of the kind value.
+func unwrap(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	if _, err := expectVariant(args, 0); err != nil {
+		return nil, err
+	}
+
+	if value, ok := unwrapped(args[0]); ok {
+		return value, nil
+	}
+
+	if result, ok := args[0].(*object.Result); ok {
+		if raised, ok := result.Value.(*object.Error); ok {
+			return nil, raised
+		}
+	}
+
+	return nil, Errorf(KindValue, "cannot unwrap %s", args[0].Inspect())
+}
+
+// Returns the value inside an Err, and raises an error for an Ok.
+func unwrapErr(_ object.Runtime, args ...object.Object) (object.Object, error) {
+	if err := expectArguments(args, 1); err != nil {
+		return nil, err
+	}
+
+	result, ok := args[0].(*object.Result)
+
+	if !ok {
+		return nil, Errorf(KindType, "expected argument 1 to be a result, but got %s", args[0].Type())
+	}
+
+	if result.Ok {
+		return nil, Errorf(KindValue, "cannot unwrap the error of %s", result.Inspect())
+	}
+
+	return result.Value, nil
+}
+
+// Returns a builtin reporting whether its argument is an Ok, or a Some.
+func isVariant(t object.ObjectType) object.BuiltinFunction {
+	return func(_ object.Runtime, args ...object.Object) (object.Object, error) {
+		if err := expectArguments(args, 1); err != nil {
+			return nil, err
+		}
+
+		if args[0].Type() != t {
+			return nil, Errorf(KindType, "expected argument 1 to be a %s, but got %s", t, args[0].Type())
+		}
+
+		_, ok := unwrapped(args[0])
+
+		return object.BoxBoolean(ok), nil
+	}
+}
@@ -115,7 +115,8 @@ func (l *Lexer) numberToken() token.Token {
 }
 
 func (l *Lexer) stringToken() token.Token {
-	lexeme := ""
+	// bytes are collected as they are, so multi-byte runes stay intact
+	lexeme := []byte{}
 
 	for char, ok := l.current(); ok; char, ok = l.next() {
 		if char == '\\' {
@@ -125,28 +126,39 @@ func (l *Lexer) stringToken() token.Token {
 			}
 
 			if char == '"' {
-				lexeme += `"`
+				lexeme = append(lexeme, '"')
 			} else if char == '\'' {
-				lexeme += `'`
+				lexeme = append(lexeme, '\'')
 			} else if char == 'n' {
-				lexeme += "\n"
+				lexeme = append(lexeme, '\n')
 			} else if char == 't' {
-				lexeme += "\t"
+				lexeme = append(lexeme, '\t')
 			} else {
-				lexeme += "\\"
-				lexeme += string(char)
+				lexeme = append(lexeme, '\\', char)
 			}
 		} else if char == l.modeChar {
 			break
 		} else {
-			lexeme += string(char)
+			lexeme = append(lexeme, char)
 		}
 	}
 
-	return l.longToken(token.STRING, lexeme)
+	return l.longToken(token.STRING, string(lexeme))
 }
 
 func (l *Lexer) specialToken() token.Token {
+	// symbols of three characters, like `...`, are matched in full before
+	// shorter ones, since their prefixes aren't necessarily symbols
+	if l.position+3 <= len(l.source) {
+		extended := l.source[l.position : l.position+3]
+		if tokenType, ok := token.SYMBOLS[extended]; ok {
+			l.next()
+			l.next()
+			l.next()
+			return l.longToken(tokenType, extended)
+		}
+	}
+
 	char, _ := l.current()
 	lexeme := string(char)
 	l.next()
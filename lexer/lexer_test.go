@@ -116,6 +116,153 @@ func TestStringLexing(t *testing.T) {
 	})
 }
 
+func TestUnicodeStringLexing(t *testing.T) {
+	test := newTest(t, "UnicodeStringLexing")
+	source := `"Grüße, 雷遁!" 'ñ'`
+
+	test.expect(source, []tokenExpect{
+		{token.DOUBLE_QUOTE, `"`},
+		{token.STRING, `Grüße, 雷遁!`},
+		{token.DOUBLE_QUOTE, `"`},
+		{token.SINGLE_QUOTE, `'`},
+		{token.STRING, `ñ`},
+		{token.SINGLE_QUOTE, `'`},
+		{token.EOF, ``},
+	})
+}
+
+func TestRecordUpdateLexing(t *testing.T) {
+	test := newTest(t, "RecordUpdateLexing")
+	source := `{ p | a: 1 ...q r.0 }`
+
+	test.expect(source, []tokenExpect{
+		{token.OPEN_BRACE, `{`},
+		{token.IDENTIFIER, `p`},
+		{token.BAR, `|`},
+		{token.IDENTIFIER, `a`},
+		{token.COLON, `:`},
+		{token.NUMBER, `1`},
+		{token.SPREAD, `...`},
+		{token.IDENTIFIER, `q`},
+		{token.IDENTIFIER, `r`},
+		{token.DOT, `.`},
+		{token.NUMBER, `0`},
+		{token.CLOSED_BRACE, `}`},
+		{token.EOF, ``},
+	})
+}
+
+func TestSelectorRangeLexing(t *testing.T) {
+	test := newTest(t, "SelectorRangeLexing")
+	source := `a.1..-1`
+
+	test.expect(source, []tokenExpect{
+		{token.IDENTIFIER, `a`},
+		{token.DOT, `.`},
+		{token.NUMBER, `1`},
+		{token.DOT_DOT, `..`},
+		{token.MINUS, `-`},
+		{token.NUMBER, `1`},
+		{token.EOF, ``},
+	})
+}
+
+func TestPipelineLexing(t *testing.T) {
+	test := newTest(t, "PipelineLexing")
+	source := `xs |> (map f>>g) | x`
+
+	test.expect(source, []tokenExpect{
+		{token.IDENTIFIER, `xs`},
+		{token.PIPE, `|>`},
+		{token.OPEN_PAREN, `(`},
+		{token.IDENTIFIER, `map`},
+		{token.IDENTIFIER, `f`},
+		{token.COMPOSE, `>>`},
+		{token.IDENTIFIER, `g`},
+		{token.CLOSED_PAREN, `)`},
+		{token.BAR, `|`},
+		{token.IDENTIFIER, `x`},
+		{token.EOF, ``},
+	})
+}
+
+func TestAssignmentLexing(t *testing.T) {
+	test := newTest(t, "AssignmentLexing")
+	source := `total := (add total: 1)`
+
+	test.expect(source, []tokenExpect{
+		{token.IDENTIFIER, `total`},
+		{token.ASSIGN, `:=`},
+		{token.OPEN_PAREN, `(`},
+		{token.IDENTIFIER, `add`},
+		{token.IDENTIFIER, `total`},
+		{token.COLON, `:`},
+		{token.NUMBER, `1`},
+		{token.CLOSED_PAREN, `)`},
+		{token.EOF, ``},
+	})
+}
+
+func TestLoopLexing(t *testing.T) {
+	test := newTest(t, "LoopLexing")
+	source := `for x in xs { break continue inside }`
+
+	test.expect(source, []tokenExpect{
+		{token.FOR, `for`},
+		{token.IDENTIFIER, `x`},
+		{token.IN, `in`},
+		{token.IDENTIFIER, `xs`},
+		{token.OPEN_BRACE, `{`},
+		{token.BREAK, `break`},
+		{token.CONTINUE, `continue`},
+		{token.IDENTIFIER, `inside`},
+		{token.CLOSED_BRACE, `}`},
+		{token.EOF, ``},
+	})
+}
+
+func TestTryLexing(t *testing.T) {
+	test := newTest(t, "TryLexing")
+	source := `try { x } catch e { e } finally { trying }`
+
+	test.expect(source, []tokenExpect{
+		{token.TRY, `try`},
+		{token.OPEN_BRACE, `{`},
+		{token.IDENTIFIER, `x`},
+		{token.CLOSED_BRACE, `}`},
+		{token.CATCH, `catch`},
+		{token.IDENTIFIER, `e`},
+		{token.OPEN_BRACE, `{`},
+		{token.IDENTIFIER, `e`},
+		{token.CLOSED_BRACE, `}`},
+		{token.FINALLY, `finally`},
+		{token.OPEN_BRACE, `{`},
+		{token.IDENTIFIER, `trying`},
+		{token.CLOSED_BRACE, `}`},
+		{token.EOF, ``},
+	})
+}
+
+func TestPropagationLexing(t *testing.T) {
+	test := newTest(t, "PropagationLexing")
+	source := `(parse s)? set!? x.y?`
+
+	test.expect(source, []tokenExpect{
+		{token.OPEN_PAREN, `(`},
+		{token.IDENTIFIER, `parse`},
+		{token.IDENTIFIER, `s`},
+		{token.CLOSED_PAREN, `)`},
+		{token.QUESTION, `?`},
+		{token.IDENTIFIER, `set!`},
+		{token.QUESTION, `?`},
+		{token.IDENTIFIER, `x`},
+		{token.DOT, `.`},
+		{token.IDENTIFIER, `y`},
+		{token.QUESTION, `?`},
+		{token.EOF, ``},
+	})
+}
+
 func TestSkippingSpaces(t *testing.T) {
 	test := newTest(t, "TestSkippingSpaces")
 	source := `  println  123.1 "Raiton"  `
@@ -0,0 +1,628 @@
+package object
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Struct fields are converted to record fields named after the `raiton`
+// tag, or after the field name in snake case if there is no tag. A field
+// tagged with `raiton:"-"` is skipped.
+const tagName = "raiton"
+
+var (
+	objectType  = reflect.TypeOf((*Object)(nil)).Elem()
+	runtimeType = reflect.TypeOf((*Runtime)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	bigIntType  = reflect.TypeOf(big.Int{})
+)
+
+// Converts a Go value to an object. Integers, floats, strings and booleans
+// map to their scalar objects, slices and arrays to slices and arrays, and
+// structs and maps with string keys to records. Unsigned integers beyond
+// the int64 range and big.Int values too large for it become big integers.
+// Functions are wrapped as builtins using WrapFunc. Values that already are
+// objects are returned as they are.
+func ToObject(v any) (Object, error) {
+	if obj, ok := v.(Object); ok {
+		return obj, nil
+	}
+
+	if v == nil {
+		return nil, fmt.Errorf("cannot convert nil to an object")
+	}
+
+	return toObject(reflect.ValueOf(v), visits{})
+}
+
+// Identifies a pointer, map or slice while its value is being converted,
+// so a value which refers back to itself is reported instead of converted
+// until the stack overflows.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+type visits map[visit]bool
+
+// Marks the value as being converted, or returns an error if it already
+// is, because it refers to itself. The returned function unmarks it.
+func (vs visits) enter(v reflect.Value) (func(), error) {
+	key := visit{ptr: v.Pointer(), typ: v.Type()}
+
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+
+	if vs[key] {
+		return nil, fmt.Errorf("cannot convert %s to an object; it refers to itself", v.Type())
+	}
+
+	vs[key] = true
+
+	return func() { delete(vs, key) }, nil
+}
+
+func toObject(v reflect.Value, vs visits) (Object, error) {
+	if v.Type().Implements(objectType) {
+		if v.Kind() == reflect.Interface && v.IsNil() {
+			return nil, fmt.Errorf("cannot convert nil to an object")
+		}
+		return v.Interface().(Object), nil
+	}
+
+	if v.Type() == bigIntType {
+		value := v.Interface().(big.Int)
+		return MakeInteger(new(big.Int).Set(&value)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return BoxBoolean(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return MakeInteger(new(big.Int).SetUint64(v.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return &Float{Value: v.Float()}, nil
+	case reflect.String:
+		return &String{Value: v.String()}, nil
+	case reflect.Slice:
+		if v.Len() > 0 {
+			leave, err := vs.enter(v)
+			if err != nil {
+				return nil, err
+			}
+			defer leave()
+		}
+		array, err := toArray(v, vs)
+		if err != nil {
+			return nil, err
+		}
+		return SliceOf(array), nil
+	case reflect.Array:
+		return toArray(v, vs)
+	case reflect.Map:
+		if !v.IsNil() {
+			leave, err := vs.enter(v)
+			if err != nil {
+				return nil, err
+			}
+			defer leave()
+		}
+		return mapToRecord(v, vs)
+	case reflect.Struct:
+		return structToRecord(v, vs)
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, fmt.Errorf("cannot convert nil %s to an object", v.Type())
+		}
+		if v.Kind() == reflect.Pointer {
+			leave, err := vs.enter(v)
+			if err != nil {
+				return nil, err
+			}
+			defer leave()
+		}
+		return toObject(v.Elem(), vs)
+	case reflect.Func:
+		return wrapFunc(v)
+	default:
+		return nil, fmt.Errorf("cannot convert %s to an object", v.Type())
+	}
+}
+
+func toArray(v reflect.Value, vs visits) (*Array, error) {
+	objs := make([]Object, v.Len())
+
+	for i := range objs {
+		obj, err := toObject(v.Index(i), vs)
+		if err != nil {
+			return nil, err
+		}
+		objs[i] = obj
+	}
+
+	return &Array{
+		Value: objs,
+		Size:  uint64(len(objs)),
+	}, nil
+}
+
+func mapToRecord(v reflect.Value, vs visits) (*Record, error) {
+	if v.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("cannot convert %s to a record; keys must be strings", v.Type())
+	}
+
+	record := NewRecord()
+
+	// Go maps have no order, so the fields are ordered by name
+	keys := v.MapKeys()
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	for _, key := range keys {
+		obj, err := toObject(v.MapIndex(key), vs)
+		if err != nil {
+			return nil, err
+		}
+		record.Set(key.String(), obj)
+	}
+
+	return record, nil
+}
+
+func structToRecord(v reflect.Value, vs visits) (*Record, error) {
+	record := NewRecord()
+
+	for _, f := range structFields(v.Type()) {
+		obj, err := toObject(v.Field(f.index), vs)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.name, err)
+		}
+		record.Set(f.name, obj)
+	}
+
+	return record, nil
+}
+
+// Converts the object to a Go value and stores it in the value pointed to
+// by target. The conversions are the inverse of ToObject's. Integers also
+// convert to floats and to big.Int values, and characters to strings and
+// runes. A target whose type is an interface, like any, gets the Go value
+// the object converts to if that implements the interface, so arrays become
+// []any and records map[string]any, or else the object itself.
+func FromObject(obj Object, target any) error {
+	v := reflect.ValueOf(target)
+
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("expected target to be a non-nil pointer, but got %T", target)
+	}
+
+	return fromObject(obj, v.Elem(), nil)
+}
+
+func fromObject(obj Object, v reflect.Value, rt Runtime) error {
+	t := v.Type()
+
+	if t.Kind() == reflect.Interface {
+		if gt := goType(obj); gt != nil && gt.Implements(t) {
+			value := reflect.New(gt).Elem()
+			if err := fromObject(obj, value, rt); err != nil {
+				return err
+			}
+			v.Set(value)
+			return nil
+		}
+		if !reflect.TypeOf(obj).Implements(t) {
+			return conversionError(obj, t)
+		}
+		v.Set(reflect.ValueOf(obj))
+		return nil
+	}
+
+	if t == bigIntType {
+		switch i := obj.(type) {
+		case *Integer:
+			v.Addr().Interface().(*big.Int).SetInt64(i.Value)
+		case *BigInt:
+			v.Addr().Interface().(*big.Int).Set(i.Value)
+		default:
+			return conversionError(obj, t)
+		}
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		b, ok := obj.(*Boolean)
+		if !ok {
+			return conversionError(obj, t)
+		}
+		v.SetBool(b.Value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if c, ok := obj.(*Character); ok && t.Kind() == reflect.Int32 {
+			r := []rune(c.Value)
+			if len(r) != 1 {
+				return fmt.Errorf("cannot convert character %s to a rune", c.Inspect())
+			}
+			v.SetInt(int64(r[0]))
+			return nil
+		}
+		i, ok := obj.(*Integer)
+		if !ok {
+			return conversionError(obj, t)
+		}
+		if v.OverflowInt(i.Value) {
+			return fmt.Errorf("integer %d overflows %s", i.Value, t)
+		}
+		v.SetInt(i.Value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch i := obj.(type) {
+		case *Integer:
+			if i.Value < 0 || v.OverflowUint(uint64(i.Value)) {
+				return fmt.Errorf("integer %d overflows %s", i.Value, t)
+			}
+			v.SetUint(uint64(i.Value))
+		case *BigInt:
+			// unsigned integers above the largest int64 are big integers
+			if !i.Value.IsUint64() || v.OverflowUint(i.Value.Uint64()) {
+				return fmt.Errorf("integer %s overflows %s", i.Value, t)
+			}
+			v.SetUint(i.Value.Uint64())
+		default:
+			return conversionError(obj, t)
+		}
+	case reflect.Float32, reflect.Float64:
+		switch n := obj.(type) {
+		case *Float:
+			v.SetFloat(n.Value)
+		case *Integer:
+			v.SetFloat(float64(n.Value))
+		case *BigInt:
+			f, _ := new(big.Float).SetInt(n.Value).Float64()
+			v.SetFloat(f)
+		default:
+			return conversionError(obj, t)
+		}
+	case reflect.String:
+		switch s := obj.(type) {
+		case *String:
+			v.SetString(s.Value)
+		case *Character:
+			v.SetString(s.Value)
+		default:
+			return conversionError(obj, t)
+		}
+	case reflect.Slice:
+		objs, ok := elements(obj)
+		if !ok {
+			return conversionError(obj, t)
+		}
+		slice := reflect.MakeSlice(t, len(objs), len(objs))
+		for i, o := range objs {
+			if err := fromObject(o, slice.Index(i), rt); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		v.Set(slice)
+	case reflect.Array:
+		objs, ok := elements(obj)
+		if !ok {
+			return conversionError(obj, t)
+		}
+		if len(objs) != t.Len() {
+			return fmt.Errorf("cannot convert %d elements to %s", len(objs), t)
+		}
+		for i, o := range objs {
+			if err := fromObject(o, v.Index(i), rt); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+	case reflect.Map:
+		record, ok := obj.(*Record)
+		if !ok || t.Key().Kind() != reflect.String {
+			return conversionError(obj, t)
+		}
+		m := reflect.MakeMapWithSize(t, record.Len())
+		for field, o := range record.values {
+			elem := reflect.New(t.Elem()).Elem()
+			if err := fromObject(o, elem, rt); err != nil {
+				return fmt.Errorf("field %s: %w", field, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(field).Convert(t.Key()), elem)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		record, ok := obj.(*Record)
+		if !ok {
+			return conversionError(obj, t)
+		}
+		for _, f := range structFields(t) {
+			o, ok := record.Get(f.name)
+			if !ok {
+				return fmt.Errorf("field '%s' not defined on record", f.name)
+			}
+			if err := fromObject(o, v.Field(f.index), rt); err != nil {
+				return fmt.Errorf("field %s: %w", f.name, err)
+			}
+		}
+	case reflect.Pointer:
+		elem := reflect.New(t.Elem())
+		if err := fromObject(obj, elem.Elem(), rt); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Func:
+		if rt == nil || (obj.Type() != FUNCTION && obj.Type() != BUILTIN) {
+			return conversionError(obj, t)
+		}
+		v.Set(makeCallback(obj, t, rt))
+	default:
+		return conversionError(obj, t)
+	}
+
+	return nil
+}
+
+// Returns the Go type an object converts to when the target's type does not
+// say, or nil if the object has no Go counterpart, like functions.
+func goType(obj Object) reflect.Type {
+	switch obj.(type) {
+	case *Boolean:
+		return reflect.TypeOf(false)
+	case *Integer:
+		return reflect.TypeOf(int64(0))
+	case *BigInt:
+		return reflect.TypeOf((*big.Int)(nil))
+	case *Float:
+		return reflect.TypeOf(float64(0))
+	case *String, *Character:
+		return reflect.TypeOf("")
+	case *Array, *Slice:
+		return reflect.TypeOf([]any(nil))
+	case *Record:
+		return reflect.TypeOf(map[string]any(nil))
+	default:
+		return nil
+	}
+}
+
+func elements(obj Object) ([]Object, bool) {
+	switch obj := obj.(type) {
+	case *Array:
+		return obj.Value, true
+	case *Slice:
+		return obj.Elements(), true
+	default:
+		return nil, false
+	}
+}
+
+func conversionError(obj Object, t reflect.Type) error {
+	return fmt.Errorf("cannot convert %s to %s", obj.Type(), t)
+}
+
+type structField struct {
+	index int
+	name  string
+}
+
+func structFields(t reflect.Type) []structField {
+	fields := []structField{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Tag.Get(tagName)
+
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = snakeCase(f.Name)
+		}
+
+		fields = append(fields, structField{index: i, name: name})
+	}
+
+	return fields
+}
+
+func snakeCase(name string) string {
+	var sb strings.Builder
+
+	runes := []rune(name)
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// start a new word on a lower to upper change, or at the
+			// last capital of an acronym, like in `HTTPServer`
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				sb.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// Wraps a Go function as a builtin. Arguments are converted with the same
+// rules as FromObject and checked against the function's parameter count
+// and types before it is called. The function may take an object.Runtime
+// as its first parameter, and may return a value, a value and an error,
+// or only an error.
+// Functions that only return an error, or nothing at all, evaluate to true.
+// Parameters of function type accept Raiton functions.
+func WrapFunc(fn any) (*Builtin, error) {
+	v := reflect.ValueOf(fn)
+
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("expected a function, but got %T", fn)
+	}
+
+	return wrapFunc(v)
+}
+
+// Like WrapFunc, but panics if the function can't be wrapped.
+func MustWrapFunc(fn any) *Builtin {
+	builtin, err := WrapFunc(fn)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return builtin
+}
+
+func wrapFunc(fn reflect.Value) (*Builtin, error) {
+	t := fn.Type()
+
+	withRuntime := t.NumIn() > 0 && t.In(0) == runtimeType
+
+	first := 0
+	if withRuntime {
+		first = 1
+	}
+
+	params := t.NumIn() - first
+
+	// the variadic parameter may be left out, so it doesn't count towards
+	// the arity the builtin is curried to
+	arity := params
+	if t.IsVariadic() {
+		arity--
+	}
+
+	switch {
+	case t.NumOut() == 0:
+	case t.NumOut() == 1:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+	default:
+		return nil, fmt.Errorf("expected function to return a value, an error, or a value and an error, but got %s", t)
+	}
+
+	builtin := func(rt Runtime, args ...Object) (result Object, err error) {
+		if t.IsVariadic() {
+			if len(args) < params-1 {
+				return nil, fmt.Errorf("expected at least %d arguments, but got %d", params-1, len(args))
+			}
+		} else if len(args) != params {
+			return nil, fmt.Errorf("expected %d arguments, but got %d", params, len(args))
+		}
+
+		in := make([]reflect.Value, 0, first+len(args))
+
+		if withRuntime {
+			in = append(in, reflect.ValueOf(&rt).Elem())
+		}
+
+		for i, arg := range args {
+			var paramType reflect.Type
+
+			if t.IsVariadic() && first+i >= t.NumIn()-1 {
+				paramType = t.In(t.NumIn() - 1).Elem()
+			} else {
+				paramType = t.In(first + i)
+			}
+
+			param := reflect.New(paramType).Elem()
+
+			if err := fromObject(arg, param, rt); err != nil {
+				return nil, fmt.Errorf("argument %d: %w", i+1, err)
+			}
+
+			in = append(in, param)
+		}
+
+		defer recoverCallback(&err)
+
+		out := fn.Call(in)
+
+		if len(out) == 0 {
+			return TRUE, nil
+		}
+
+		if last := out[len(out)-1]; last.Type() == errorType {
+			if !last.IsNil() {
+				return nil, last.Interface().(error)
+			}
+			if len(out) == 1 {
+				return TRUE, nil
+			}
+		}
+
+		return toObject(out[0], visits{})
+	}
+
+	return MakeBuiltin(builtin).Curried(arity), nil
+}
+
+// Raised from within callbacks, so errors of Raiton functions called by
+// a wrapped Go function propagate to the builtin that wraps it.
+type callbackError struct {
+	err error
+}
+
+func recoverCallback(err *error) {
+	if r := recover(); r != nil {
+		cbErr, ok := r.(callbackError)
+
+		if !ok {
+			panic(r)
+		}
+
+		*err = cbErr.err
+	}
+}
+
+func makeCallback(fn Object, t reflect.Type, rt Runtime) reflect.Value {
+	return reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		args := make([]Object, len(in))
+
+		for i, v := range in {
+			obj, err := toObject(v, visits{})
+			if err != nil {
+				return callbackResult(t, nil, err)
+			}
+			args[i] = obj
+		}
+
+		obj, err := rt.Apply(fn, args...)
+
+		return callbackResult(t, obj, err)
+	})
+}
+
+func callbackResult(t reflect.Type, obj Object, err error) []reflect.Value {
+	out := make([]reflect.Value, t.NumOut())
+
+	for i := range out {
+		out[i] = reflect.New(t.Out(i)).Elem()
+	}
+
+	if err == nil && t.NumOut() > 0 && t.Out(0) != errorType {
+		err = fromObject(obj, out[0], nil)
+	}
+
+	if err != nil {
+		if t.NumOut() == 0 || t.Out(t.NumOut()-1) != errorType {
+			panic(callbackError{err: err})
+		}
+		out[t.NumOut()-1].Set(reflect.ValueOf(&err).Elem())
+	}
+
+	return out
+}
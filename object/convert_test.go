@@ -0,0 +1,330 @@
+package object
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+type person struct {
+	Name     string
+	Age      int
+	Nickname string `raiton:"alias"`
+	Secret   string `raiton:"-"`
+}
+
+func TestToObjectScalars(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{42, "42"},
+		{uint8(7), "7"},
+		{uint64(math.MaxUint64), "18446744073709551615"},
+		{big.NewInt(12), "12"},
+		{2.5, "2.5"},
+		{"raiton", `"raiton"`},
+		{true, "true"},
+		{[]int{1, 2, 3}, "[1 2 3]"},
+		{[2]string{"a", "b"}, `[2: "a" "b"]`},
+	}
+
+	for _, tt := range tests {
+		obj, err := ToObject(tt.value)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if obj.Inspect() != tt.expected {
+			t.Errorf("expected %s, but got %s", tt.expected, obj.Inspect())
+		}
+	}
+}
+
+func TestStructRoundTrip(t *testing.T) {
+	obj, err := ToObject(person{Name: "Tojuro", Age: 24, Nickname: "T", Secret: "x"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record, ok := obj.(*Record)
+
+	if !ok {
+		t.Fatalf("expected a record, but got %s", obj.Type())
+	}
+
+	for _, field := range []string{"name", "age", "alias"} {
+		if _, ok := record.Get(field); !ok {
+			t.Errorf("expected field %s on record", field)
+		}
+	}
+
+	if _, ok := record.Get("secret"); ok {
+		t.Errorf("expected field secret to be skipped")
+	}
+
+	var p person
+
+	if err := FromObject(record, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Name != "Tojuro" || p.Age != 24 || p.Nickname != "T" || p.Secret != "" {
+		t.Errorf("unexpected round trip result %+v", p)
+	}
+}
+
+func TestBigIntegerRoundTrip(t *testing.T) {
+	value, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+
+	obj, err := ToObject(value)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := obj.(*BigInt); !ok {
+		t.Fatalf("expected a big integer, but got %T", obj)
+	}
+
+	var result big.Int
+
+	if err := FromObject(obj, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Cmp(value) != 0 {
+		t.Errorf("expected %s, but got %s", value, &result)
+	}
+
+	var small *big.Int
+
+	if err := FromObject(&Integer{Value: 5}, &small); err != nil {
+		t.Fatal(err)
+	}
+
+	if small.Int64() != 5 {
+		t.Errorf("expected 5, but got %s", small)
+	}
+}
+
+func TestUnsignedBigIntegerRoundTrip(t *testing.T) {
+	obj, err := ToObject(uint64(math.MaxUint64))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := obj.(*BigInt); !ok {
+		t.Fatalf("expected a big integer, but got %T", obj)
+	}
+
+	var result uint64
+
+	if err := FromObject(obj, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if result != math.MaxUint64 {
+		t.Errorf("expected %d, but got %d", uint64(math.MaxUint64), result)
+	}
+
+	var small uint32
+
+	if err := FromObject(obj, &small); err == nil || err.Error() != "integer 18446744073709551615 overflows uint32" {
+		t.Errorf("expected overflow error, but got %v", err)
+	}
+
+	negative, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+
+	if err := FromObject(&BigInt{Value: negative}, &result); err == nil {
+		t.Errorf("expected overflow error")
+	}
+}
+
+func TestFromObjectErrors(t *testing.T) {
+	var i int8
+
+	if err := FromObject(&Integer{Value: 300}, &i); err == nil {
+		t.Errorf("expected overflow error")
+	}
+
+	if err := FromObject(&String{Value: "1"}, &i); err == nil {
+		t.Errorf("expected conversion error")
+	}
+
+	var nums []float64
+
+	slice := NewSlice([]Object{&Integer{Value: 1}, &Float{Value: 1.5}})
+
+	if err := FromObject(slice, &nums); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nums) != 2 || nums[0] != 1 || nums[1] != 1.5 {
+		t.Errorf("unexpected slice %v", nums)
+	}
+}
+
+func TestFromObjectInterface(t *testing.T) {
+	record := NewRecord()
+	record.Set("name", &String{Value: "Ada"})
+	record.Set("tags", NewSlice([]Object{&String{Value: "a"}, &Integer{Value: 1}}))
+
+	var result any
+
+	if err := FromObject(record, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	m, ok := result.(map[string]any)
+
+	if !ok {
+		t.Fatalf("expected map[string]any, but got %T", result)
+	}
+
+	if m["name"] != "Ada" {
+		t.Errorf("expected name \"Ada\", but got %#v", m["name"])
+	}
+
+	tags, ok := m["tags"].([]any)
+
+	if !ok || len(tags) != 2 || tags[0] != "a" || tags[1] != int64(1) {
+		t.Errorf("unexpected tags %#v", m["tags"])
+	}
+
+	var obj Object
+
+	if err := FromObject(&Integer{Value: 1}, &obj); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := obj.(*Integer); !ok {
+		t.Errorf("expected an integer object, but got %T", obj)
+	}
+
+	var s fmt.Stringer
+
+	if err := FromObject(&Integer{Value: 1}, &s); err == nil {
+		t.Errorf("expected conversion error")
+	}
+}
+
+type node struct {
+	Value int
+	Next  *node
+}
+
+func TestToObjectCycle(t *testing.T) {
+	n := &node{Value: 1}
+	n.Next = n
+
+	if _, err := ToObject(n); err == nil || !strings.Contains(err.Error(), "refers to itself") {
+		t.Errorf("expected cycle error, but got %v", err)
+	}
+
+	m := map[string]any{}
+	m["self"] = m
+
+	if _, err := ToObject(m); err == nil {
+		t.Errorf("expected cycle error")
+	}
+
+	// values referred to more than once are not cycles
+	shared := &person{Name: "Ada"}
+
+	if _, err := ToObject([]*person{shared, shared}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+type testRuntime struct{}
+
+func (testRuntime) Apply(fn Object, args ...Object) (Object, error) {
+	return fn.(*Builtin).Fn(testRuntime{}, args...)
+}
+
+func (testRuntime) Stdout() io.Writer { return io.Discard }
+
+func (testRuntime) Stderr() io.Writer { return io.Discard }
+
+func TestWrapFunc(t *testing.T) {
+	repeat := MustWrapFunc(func(s string, n int) (string, error) {
+		if n < 0 {
+			return "", fmt.Errorf("negative count")
+		}
+		return strings.Repeat(s, n), nil
+	})
+
+	obj, err := repeat.Fn(testRuntime{}, &String{Value: "ab"}, &Integer{Value: 2})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if obj.Inspect() != `"abab"` {
+		t.Errorf("expected \"abab\", but got %s", obj.Inspect())
+	}
+
+	if repeat.Arity != 2 {
+		t.Errorf("expected arity 2, but got %d", repeat.Arity)
+	}
+
+	if _, err := repeat.Fn(testRuntime{}, &String{Value: "ab"}); err == nil {
+		t.Errorf("expected arity error")
+	}
+
+	if _, err := repeat.Fn(testRuntime{}, &String{Value: "ab"}, &String{Value: "2"}); err == nil {
+		t.Errorf("expected type error")
+	}
+
+	if _, err := repeat.Fn(testRuntime{}, &String{Value: "ab"}, &Integer{Value: -1}); err == nil {
+		t.Errorf("expected error returned by the function")
+	}
+}
+
+func TestWrapFuncCallback(t *testing.T) {
+	apply := MustWrapFunc(func(f func(int) int, n int) int {
+		return f(n)
+	})
+
+	double := MustWrapFunc(func(n int) int { return n * 2 })
+
+	obj, err := apply.Fn(testRuntime{}, double, &Integer{Value: 21})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if obj.Inspect() != "42" {
+		t.Errorf("expected 42, but got %s", obj.Inspect())
+	}
+
+	failing := MakeBuiltin(func(_ Runtime, _ ...Object) (Object, error) {
+		return nil, fmt.Errorf("callback failed")
+	})
+
+	if _, err := apply.Fn(testRuntime{}, failing, &Integer{Value: 21}); err == nil || err.Error() != "callback failed" {
+		t.Errorf("expected callback error to propagate, but got %v", err)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"Name":       "name",
+		"FirstName":  "first_name",
+		"HTTPServer": "http_server",
+		"ID":         "id",
+	}
+
+	for in, expected := range tests {
+		if got := snakeCase(in); got != expected {
+			t.Errorf("expected %s, but got %s", expected, got)
+		}
+	}
+}
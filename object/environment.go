@@ -1,8 +1,25 @@
 package object
 
+import "raiton/ast"
+
+// Environment holds the bindings of a scope. Bindings resolved to slots
+// are stored in a slice, and the rest in a map, which is only allocated
+// once a binding is defined by name.
 type Environment struct {
 	enclosing *Environment
 	symbols   map[string]Object
+	names     []ast.Identifier
+	slots     []Object
+	// the call the frame belongs to, nil outside of functions
+	call *Call
+}
+
+// An application of a function, which a ? in it returns from. The frames
+// of tail calls belong to the call they replace, since they return in its
+// place, and those of loop bodies and handlers to the call around them.
+type Call struct {
+	// the function applied first
+	Function *Function
 }
 
 func NewEnvironment() *Environment {
@@ -12,25 +29,71 @@ func NewEnvironment() *Environment {
 func NewEnclosedEnvironment(env *Environment) *Environment {
 	return &Environment{
 		enclosing: env,
-		symbols:   map[string]Object{},
+	}
+}
+
+// Creates an environment with a slot for each of the names,
+// as resolved for a function's parameters and local definitions.
+func NewFrame(env *Environment, names []ast.Identifier) *Environment {
+	return &Environment{
+		enclosing: env,
+		names:     names,
+		slots:     make([]Object, len(names)),
 	}
 }
 
 func (e *Environment) Define(name string, value Object) Object {
+	if e.symbols == nil {
+		e.symbols = map[string]Object{}
+	}
+
 	e.symbols[name] = value
 	return value
 }
 
+// Looks the name up in this and the enclosing environments. Slots are
+// looked up by their names too, as long as they have been assigned.
 func (e *Environment) Lookup(name string) (Object, bool) {
-	obj, ok := e.symbols[name]
+	for env := e; env != nil; env = env.enclosing {
+		if obj, ok := env.symbols[name]; ok {
+			return obj, true
+		}
+
+		for i, n := range env.names {
+			if string(n) == name && env.slots[i] != nil {
+				return env.slots[i], true
+			}
+		}
+	}
+
+	return nil, false
+}
 
-	if !ok && e.enclosing != nil {
-		return e.enclosing.Lookup(name)
+// Returns the value of the slot in the environment depth levels up,
+// or nil if the slot has not been assigned.
+func (e *Environment) Get(depth int, index int) Object {
+	env := e
+
+	for ; depth > 0; depth-- {
+		env = env.enclosing
 	}
 
-	return obj, ok
+	return env.slots[index]
+}
+
+func (e *Environment) Set(index int, value Object) Object {
+	e.slots[index] = value
+	return value
 }
 
 func (e *Environment) Enclosing() *Environment {
 	return e.enclosing
 }
+
+func (e *Environment) Call() *Call {
+	return e.call
+}
+
+func (e *Environment) SetCall(call *Call) {
+	e.call = call
+}
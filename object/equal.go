@@ -0,0 +1,139 @@
+package object
+
+import (
+	"math"
+	"math/big"
+)
+
+// Reports whether the objects are deeply equal. Integers, big integers and
+// floats are compared by their numeric value, arrays, slices, records,
+// tuples, results and options element by element, and functions by identity.
+func Equal(a Object, b Object) bool {
+	switch a := a.(type) {
+	case *Integer:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value == b.Value
+		case *BigInt:
+			return b.Value.IsInt64() && b.Value.Int64() == a.Value
+		case *Float:
+			return float64(a.Value) == b.Value
+		}
+	case *BigInt:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value.IsInt64() && a.Value.Int64() == b.Value
+		case *BigInt:
+			return a.Value.Cmp(b.Value) == 0
+		case *Float:
+			return equalBigFloat(a.Value, b.Value)
+		}
+	case *Float:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value == float64(b.Value)
+		case *BigInt:
+			return equalBigFloat(b.Value, a.Value)
+		case *Float:
+			return a.Value == b.Value
+		}
+	case *Boolean:
+		if b, ok := b.(*Boolean); ok {
+			return a.Value == b.Value
+		}
+	case *String:
+		if b, ok := b.(*String); ok {
+			return a.Value == b.Value
+		}
+	case *Character:
+		if b, ok := b.(*Character); ok {
+			return a.Value == b.Value
+		}
+	case *Array:
+		if b, ok := b.(*Array); ok {
+			return a.Size == b.Size && equalElements(a.Value, b.Value)
+		}
+	case *Slice:
+		if b, ok := b.(*Slice); ok {
+			return equalElements(a.Elements(), b.Elements())
+		}
+	case *Tuple:
+		if b, ok := b.(*Tuple); ok {
+			return equalElements(a.Elements, b.Elements)
+		}
+	case *Range:
+		if b, ok := b.(*Range); ok {
+			return *a == *b
+		}
+	case *Map:
+		b, ok := b.(*Map)
+
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+
+		for _, entry := range a.Entries() {
+			other, ok, _ := b.Get(entry.Key)
+
+			if !ok || !Equal(entry.Value, other) {
+				return false
+			}
+		}
+
+		return true
+	case *Record:
+		b, ok := b.(*Record)
+
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+
+		for field, value := range a.values {
+			other, ok := b.Get(field)
+
+			if !ok || !Equal(value, other) {
+				return false
+			}
+		}
+
+		return true
+	case *Result:
+		if b, ok := b.(*Result); ok {
+			return a.Ok == b.Ok && Equal(a.Value, b.Value)
+		}
+	case *Option:
+		if b, ok := b.(*Option); ok {
+			if a.Value == nil || b.Value == nil {
+				return a.Value == b.Value
+			}
+
+			return Equal(a.Value, b.Value)
+		}
+	default:
+		return a == b
+	}
+
+	return false
+}
+
+func equalElements(a []Object, b []Object) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !Equal(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalBigFloat(a *big.Int, b float64) bool {
+	if math.IsNaN(b) || math.IsInf(b, 0) {
+		return false
+	}
+
+	return new(big.Float).SetInt(a).Cmp(big.NewFloat(b)) == 0
+}
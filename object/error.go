@@ -0,0 +1,42 @@
+package object
+
+import "fmt"
+
+// An error raised by a program or by a builtin, which programs can catch.
+// The kind tells errors apart, the message describes what went wrong, and
+// the payload is any value the error carries, an empty record if none.
+// Errors are Go errors as well, so raising one is returning it as such.
+type Error struct {
+	Kind    string
+	Message string
+	Payload Object
+}
+
+func NewError(kind string, message string, payload Object) *Error {
+	if payload == nil {
+		payload = NewRecord()
+	}
+
+	return &Error{
+		Kind:    kind,
+		Message: message,
+		Payload: payload,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Inspect() string {
+	kind := &String{Value: e.Kind}
+	message := &String{Value: e.Message}
+
+	if r, ok := e.Payload.(*Record); ok && r.Len() == 0 {
+		return fmt.Sprintf("(error %s %s)", kind.Inspect(), message.Inspect())
+	}
+
+	return fmt.Sprintf("(error %s %s %s)", kind.Inspect(), message.Inspect(), e.Payload.Inspect())
+}
+
+func (e *Error) Type() ObjectType { return ERROR }
@@ -0,0 +1,232 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Identifies a hashable object by its type and value, so equal objects have
+// equal keys. Integers and big integers of the same value share their keys.
+type HashKey string
+
+// Hashable objects can be used as map keys. Strings, characters, integers
+// and booleans are hashable, and so are arrays and tuples of hashable elements.
+type Hashable interface {
+	Object
+	// Returns the key of the object, or false if it holds unhashable elements.
+	HashKey() (HashKey, bool)
+}
+
+// Returns the key of the object, if it is hashable.
+func Hash(obj Object) (HashKey, bool) {
+	hashable, ok := obj.(Hashable)
+
+	if !ok {
+		return "", false
+	}
+
+	return hashable.HashKey()
+}
+
+func (s *String) HashKey() (HashKey, bool) {
+	return HashKey(STRING + strconv.Quote(s.Value)), true
+}
+
+func (c *Character) HashKey() (HashKey, bool) {
+	return HashKey(CHARACTER + strconv.Quote(c.Value)), true
+}
+
+func (i *Integer) HashKey() (HashKey, bool) {
+	return HashKey(INTEGER + ":" + strconv.FormatInt(i.Value, 10)), true
+}
+
+func (b *BigInt) HashKey() (HashKey, bool) {
+	return HashKey(INTEGER + ":" + b.Value.String()), true
+}
+
+func (b *Boolean) HashKey() (HashKey, bool) {
+	return HashKey(BOOLEAN + ":" + strconv.FormatBool(b.Value)), true
+}
+
+func (a *Array) HashKey() (HashKey, bool) {
+	return hashElements(ARRAY, a.Value)
+}
+
+func (t *Tuple) HashKey() (HashKey, bool) {
+	return hashElements(TUPLE, t.Elements)
+}
+
+func hashElements(typ ObjectType, elements []Object) (HashKey, bool) {
+	keys := make([]string, len(elements))
+
+	for i, element := range elements {
+		key, ok := Hash(element)
+
+		if !ok {
+			return "", false
+		}
+
+		keys[i] = string(key)
+	}
+
+	return HashKey(fmt.Sprintf("%s(%s)", typ, strings.Join(keys, " "))), true
+}
+
+type MapEntry struct {
+	Key   Object
+	Value Object
+}
+
+// Maps are immutable, so changing one makes a new map, which shares most
+// of its entries with the old one, so it takes logarithmic time rather
+// than a copy of the map. Entries are kept in the order their keys were
+// first added, which is also the order they are inspected and iterated in.
+type Map struct {
+	root *trie
+	size int
+	// the position the next new key is added at
+	next uint64
+}
+
+func NewMap() *Map {
+	return &Map{}
+}
+
+func (m *Map) Len() int {
+	return m.size
+}
+
+// Returns the value bound to the key, if there is one.
+func (m *Map) Get(key Object) (Object, bool, error) {
+	hash, err := hashKey(key)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	l, ok := m.root.get(hash, hashOf(hash), 0)
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	return l.entry.Value, true, nil
+}
+
+// Binds the key to the value in place. Only used while building a
+// map, since maps are otherwise immutable.
+func (m *Map) Set(key Object, value Object) error {
+	updated, err := m.Put(key, value)
+
+	if err != nil {
+		return err
+	}
+
+	*m = *updated
+
+	return nil
+}
+
+// Returns a map with the key bound to the value. A key which is bound
+// already keeps its place in the order.
+func (m *Map) Put(key Object, value Object) (*Map, error) {
+	hash, err := hashKey(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &leaf{
+		key:      hash,
+		hash:     hashOf(hash),
+		entry:    &MapEntry{Key: key, Value: value},
+		position: m.next,
+	}
+
+	if existing, ok := m.root.get(hash, l.hash, 0); ok {
+		l.entry.Key = existing.entry.Key
+		l.position = existing.position
+	}
+
+	root, added := m.root.put(l, 0)
+
+	updated := &Map{root: root, size: m.size, next: m.next}
+
+	if added {
+		updated.size++
+		updated.next++
+	}
+
+	return updated, nil
+}
+
+// Returns a map without the key.
+func (m *Map) Delete(key Object) (*Map, error) {
+	hash, err := hashKey(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	root, deleted := m.root.delete(hash, hashOf(hash), 0)
+
+	if !deleted {
+		return m, nil
+	}
+
+	return &Map{root: root, size: m.size - 1, next: m.next}, nil
+}
+
+// Returns the entries in order.
+func (m *Map) Entries() []*MapEntry {
+	leaves := make([]*leaf, 0, m.size)
+
+	m.root.each(func(l *leaf) {
+		leaves = append(leaves, l)
+	})
+
+	sort.Slice(leaves, func(i, j int) bool { return leaves[i].position < leaves[j].position })
+
+	entries := make([]*MapEntry, len(leaves))
+
+	for i, l := range leaves {
+		entries[i] = l.entry
+	}
+
+	return entries
+}
+
+func hashKey(key Object) (HashKey, error) {
+	hash, ok := Hash(key)
+
+	if !ok {
+		return "", NewError("key", fmt.Sprintf("cannot use %s as a map key", key.Type()), nil)
+	}
+
+	return hash, nil
+}
+
+func (m *Map) Inspect() string {
+	strs := []string{}
+
+	for _, entry := range m.Entries() {
+		strs = append(strs, fmt.Sprintf("%s: %s", entry.Key.Inspect(), entry.Value.Inspect()))
+	}
+
+	return fmt.Sprintf("%%{ %s }", strings.Join(strs, " "))
+}
+
+func (m *Map) Type() ObjectType { return MAP }
+
+// Maps are iterated as pairs of keys and values.
+func (m *Map) Iterate() Iterator {
+	pairs := make([]Object, m.size)
+
+	for i, entry := range m.Entries() {
+		pairs[i] = MakePair(entry.Key, entry.Value)
+	}
+
+	return &elementIterator{elements: pairs}
+}
@@ -0,0 +1,136 @@
+package object
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMapPersistence(t *testing.T) {
+	m := NewMap()
+
+	for _, s := range []string{"a", "b", "c"} {
+		if err := m.Set(&String{Value: s}, &Integer{Value: int64(len(m.Entries()))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	put, err := m.Put(&String{Value: "b"}, &Integer{Value: 9})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := m.Delete(&String{Value: "a"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readded, err := deleted.Put(&String{Value: "a"}, &Integer{Value: 0})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		m        *Map
+		expected string
+	}{
+		{m, `%{ "a": 0 "b": 1 "c": 2 }`},
+		{put, `%{ "a": 0 "b": 9 "c": 2 }`},
+		{deleted, `%{ "b": 1 "c": 2 }`},
+		{readded, `%{ "b": 1 "c": 2 "a": 0 }`},
+	}
+
+	for _, tt := range tests {
+		if tt.m.Inspect() != tt.expected {
+			t.Errorf("expected %s, but got %s", tt.expected, tt.m.Inspect())
+		}
+
+		if tt.m.Len() != len(tt.m.Entries()) {
+			t.Errorf("expected length %d of %s, but got %d", len(tt.m.Entries()), tt.m.Inspect(), tt.m.Len())
+		}
+	}
+}
+
+func TestMapManyKeys(t *testing.T) {
+	m := NewMap()
+	n := 10000
+
+	for i := 0; i < n; i++ {
+		var err error
+
+		if m, err = m.Put(&Integer{Value: int64(i)}, &String{Value: fmt.Sprint(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < n; i += 2 {
+		var err error
+
+		if m, err = m.Delete(&Integer{Value: int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if m.Len() != n/2 {
+		t.Fatalf("expected %d entries, but got %d", n/2, m.Len())
+	}
+
+	for i := 0; i < n; i++ {
+		value, ok, err := m.Get(&Integer{Value: int64(i)})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ok != (i%2 == 1) || ok && value.Inspect() != fmt.Sprintf("%q", fmt.Sprint(i)) {
+			t.Fatalf("unexpected value %v, %t for key %d", value, ok, i)
+		}
+	}
+
+	for i, entry := range m.Entries() {
+		if entry.Key.Inspect() != fmt.Sprint(2*i+1) {
+			t.Fatalf("expected key %d at %d, but got %s", 2*i+1, i, entry.Key.Inspect())
+		}
+	}
+}
+
+func TestMapKeyError(t *testing.T) {
+	_, err := NewMap().Put(&Float{Value: 1.5}, TRUE)
+
+	if e, ok := err.(*Error); !ok || e.Kind != "key" {
+		t.Errorf("expected key error, but got %v", err)
+	}
+}
+
+func TestTrieCollisions(t *testing.T) {
+	var root *trie
+
+	// keys whose hashes are equal, or only differ in the last bits
+	leaves := []*leaf{
+		{key: "a", hash: 1},
+		{key: "b", hash: 1},
+		{key: "c", hash: 1 | 1<<63},
+	}
+
+	for _, l := range leaves {
+		root, _ = root.put(l, 0)
+	}
+
+	for _, l := range leaves {
+		if found, ok := root.get(l.key, l.hash, 0); !ok || found != l {
+			t.Errorf("expected to find key %s", l.key)
+		}
+	}
+
+	root, _ = root.delete("a", 1, 0)
+
+	if _, ok := root.get("a", 1, 0); ok {
+		t.Errorf("expected key a to be deleted")
+	}
+
+	if _, ok := root.get("b", 1, 0); !ok {
+		t.Errorf("expected to find key b")
+	}
+}
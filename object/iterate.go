@@ -0,0 +1,123 @@
+package object
+
+import (
+	"unicode/utf8"
+)
+
+// Iterable objects can be visited element by element: arrays, slices and tuples
+// yield their elements, strings their characters, ranges their integers,
+// and maps and records pairs of their keys or field names and values.
+type Iterable interface {
+	Object
+	Iterate() Iterator
+}
+
+// Iterators return the next element and true, or false once exhausted.
+type Iterator interface {
+	Next() (Object, bool)
+}
+
+// Returns an iterator over the object, if it is iterable.
+func Iterate(obj Object) (Iterator, bool) {
+	iterable, ok := obj.(Iterable)
+
+	if !ok {
+		return nil, false
+	}
+
+	return iterable.Iterate(), true
+}
+
+// Collects the remaining elements of the iterator.
+func Collect(it Iterator) []Object {
+	objs := []Object{}
+
+	for obj, ok := it.Next(); ok; obj, ok = it.Next() {
+		objs = append(objs, obj)
+	}
+
+	return objs
+}
+
+// Returns a tuple of two elements, which is how pairs like the fields of
+// records are represented, so they can be destructured.
+func MakePair(first Object, second Object) *Tuple {
+	return &Tuple{Elements: []Object{first, second}}
+}
+
+type elementIterator struct {
+	elements []Object
+	index    int
+}
+
+func (it *elementIterator) Next() (Object, bool) {
+	if it.index >= len(it.elements) {
+		return nil, false
+	}
+
+	it.index += 1
+
+	return it.elements[it.index-1], true
+}
+
+type stringIterator struct {
+	value string
+}
+
+func (it *stringIterator) Next() (Object, bool) {
+	if it.value == "" {
+		return nil, false
+	}
+
+	_, size := utf8.DecodeRuneInString(it.value)
+	c := &Character{Value: it.value[:size]}
+	it.value = it.value[size:]
+
+	return c, true
+}
+
+type rangeIterator struct {
+	next  int64
+	end   int64
+	step  int64
+	empty bool
+}
+
+func (it *rangeIterator) Next() (Object, bool) {
+	if it.empty || (it.step > 0 && it.next >= it.end) || (it.step < 0 && it.next <= it.end) {
+		return nil, false
+	}
+
+	i := it.next
+	it.next += it.step
+
+	// stop instead of wrapping around
+	if (it.step > 0 && it.next < i) || (it.step < 0 && it.next > i) {
+		it.empty = true
+	}
+
+	return &Integer{Value: i}, true
+}
+
+func (a *Array) Iterate() Iterator { return &elementIterator{elements: a.Value} }
+
+func (s *Slice) Iterate() Iterator { return &elementIterator{elements: s.Elements()} }
+
+func (t *Tuple) Iterate() Iterator { return &elementIterator{elements: t.Elements} }
+
+func (s *String) Iterate() Iterator { return &stringIterator{value: s.Value} }
+
+func (r *Range) Iterate() Iterator {
+	return &rangeIterator{next: r.Start, end: r.End, step: r.Step, empty: r.Step == 0}
+}
+
+func (r *Record) Iterate() Iterator {
+	fields := r.Names()
+	pairs := make([]Object, len(fields))
+
+	for i, field := range fields {
+		pairs[i] = MakePair(&String{Value: field}, r.values[field])
+	}
+
+	return &elementIterator{elements: pairs}
+}
@@ -0,0 +1,42 @@
+package object
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIterate(t *testing.T) {
+	record := NewRecord()
+	record.Set("b", &Integer{Value: 2})
+	record.Set("a", &Integer{Value: 1})
+
+	tests := []struct {
+		value    Object
+		expected string
+	}{
+		{&String{Value: "añ"}, `['a' 'ñ']`},
+		{record, `[("b", 2) ("a", 1)]`},
+		{&Range{Start: 0, End: 3, Step: 1}, `[0 1 2]`},
+		{&Range{Start: 3, End: 0, Step: -2}, `[3 1]`},
+		{&Range{Start: 0, End: 3, Step: -1}, `[]`},
+		{&Range{Start: math.MaxInt64 - 1, End: math.MaxInt64, Step: 5}, `[9223372036854775806]`},
+	}
+
+	for _, tt := range tests {
+		it, ok := Iterate(tt.value)
+
+		if !ok {
+			t.Fatalf("expected %s to be iterable", tt.value.Inspect())
+		}
+
+		elements := NewSlice(Collect(it))
+
+		if elements.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.value.Inspect(), tt.expected, elements.Inspect())
+		}
+	}
+
+	if _, ok := Iterate(&Integer{Value: 1}); ok {
+		t.Errorf("expected integers not to be iterable")
+	}
+}
@@ -2,6 +2,10 @@ package object
 
 import (
 	"fmt"
+	"io"
+	"maps"
+	"math/big"
+	"slices"
 	"strings"
 
 	"raiton/ast"
@@ -22,9 +26,17 @@ const (
 	STRING    = "string"
 	ARRAY     = "array"
 	SLICE     = "slice"
+	TUPLE     = "tuple"
+	RANGE     = "range"
 	RECORD    = "record"
+	MAP       = "map"
+	REF       = "ref"
+	ERROR     = "error"
+	RESULT    = "result"
+	OPTION    = "option"
 	FUNCTION  = "function"
 	BUILTIN   = "builtin"
+	THUNK     = "thunk"
 )
 
 type Boolean struct {
@@ -63,6 +75,27 @@ func (i *Integer) Inspect() string { return fmt.Sprintf("%d", i.Value) }
 
 func (i *Integer) Type() ObjectType { return INTEGER }
 
+// An integer too large for an int64. Arithmetic on integers switches to
+// big integers when a result overflows, and back once results fit again,
+// so a big integer always holds a value outside of the int64 range. Big
+// integers are integers as far as the language is concerned.
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Inspect() string { return b.Value.String() }
+
+func (b *BigInt) Type() ObjectType { return INTEGER }
+
+// Returns an integer holding the value, which is only big if it has to be.
+func MakeInteger(value *big.Int) Object {
+	if value.IsInt64() {
+		return &Integer{Value: value.Int64()}
+	}
+
+	return &BigInt{Value: value}
+}
+
 type Float struct {
 	Value float64
 }
@@ -96,14 +129,95 @@ func (a *Array) Inspect() string {
 
 func (a *Array) Type() ObjectType { return ARRAY }
 
+// Slices are views of a window of a backing array, like in Go. Views made
+// from the same array share its elements, so appending to a view whose
+// window ends before the backing array does overwrites the elements after
+// it. A view's capacity is the rest of the backing array from its offset.
 type Slice struct {
-	Value *Array
+	Value  *Array
+	Offset uint64
+	Length uint64
+}
+
+// Returns a slice of the elements, which is the only view of its backing array.
+func NewSlice(elements []Object) *Slice {
+	return &Slice{
+		Value: &Array{
+			Value: elements,
+			Size:  uint64(len(elements)),
+		},
+		Length: uint64(len(elements)),
+	}
+}
+
+// Returns a view of the whole array.
+func SliceOf(array *Array) *Slice {
+	return &Slice{
+		Value:  array,
+		Length: uint64(len(array.Value)),
+	}
+}
+
+// Returns the elements in the window of the slice.
+func (s *Slice) Elements() []Object {
+	return s.Value.Value[s.Offset : s.Offset+s.Length]
+}
+
+func (s *Slice) Cap() uint64 {
+	return uint64(len(s.Value.Value)) - s.Offset
+}
+
+// Returns a view of the elements from low up to, but not including, high,
+// sharing the backing array. As in Go, high may reach past the length of
+// the slice, up to its capacity.
+func (s *Slice) Slice(low uint64, high uint64) (*Slice, error) {
+	if low > high || high > s.Cap() {
+		return nil, fmt.Errorf("slice bounds %d..%d are out of range for capacity %d", low, high, s.Cap())
+	}
+
+	return &Slice{
+		Value:  s.Value,
+		Offset: s.Offset + low,
+		Length: high - low,
+	}, nil
+}
+
+// Returns a slice with the elements added at its end. The elements are
+// stored in the backing array if it has room for them, or else in a new
+// backing array with double the capacity, so appending repeatedly takes
+// amortized constant time.
+func (s *Slice) Append(elements ...Object) *Slice {
+	length := s.Length + uint64(len(elements))
+
+	if length <= s.Cap() {
+		copy(s.Value.Value[s.Offset+s.Length:], elements)
+
+		return &Slice{
+			Value:  s.Value,
+			Offset: s.Offset,
+			Length: length,
+		}
+	}
+
+	capacity := max(2*s.Cap(), length, 4)
+	backing := make([]Object, length, capacity)
+
+	copy(backing, s.Elements())
+	copy(backing[s.Length:], elements)
+
+	return &Slice{
+		Value: &Array{
+			Value: backing[:capacity],
+			Size:  capacity,
+		},
+		Length: length,
+	}
 }
 
 func (s *Slice) Inspect() string {
 	strs := []string{}
 
-	for _, o := range s.Value.Value {
+	for _, o := range s.Elements() {
 		strs = append(strs, o.Inspect())
 	}
 
@@ -112,15 +226,114 @@ func (s *Slice) Inspect() string {
 
 func (s *Slice) Type() ObjectType { return SLICE }
 
+// A fixed number of values, which are usually of different types, like
+// the quotient and the remainder of a division.
+type Tuple struct {
+	Elements []Object
+}
+
+func (t *Tuple) Inspect() string {
+	strs := []string{}
+
+	for _, o := range t.Elements {
+		strs = append(strs, o.Inspect())
+	}
+
+	if len(strs) == 1 {
+		return fmt.Sprintf("(%s,)", strs[0])
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(strs, ", "))
+}
+
+func (t *Tuple) Type() ObjectType { return TUPLE }
+
+// The integers from Start up to, but not including, End, counting by Step.
+// Ranges are iterated lazily, so they don't hold their elements.
+type Range struct {
+	Start int64
+	End   int64
+	Step  int64
+}
+
+func (r *Range) Inspect() string {
+	if r.Step == 1 {
+		return fmt.Sprintf("(range %d %d)", r.Start, r.End)
+	}
+
+	return fmt.Sprintf("(range %d %d %d)", r.Start, r.End, r.Step)
+}
+
+func (r *Range) Type() ObjectType { return RANGE }
+
+// Returns the number of integers in the range.
+func (r *Range) Len() uint64 {
+	var distance, step uint64
+
+	switch {
+	case r.Step > 0 && r.End > r.Start:
+		distance, step = uint64(r.End)-uint64(r.Start), uint64(r.Step)
+	case r.Step < 0 && r.End < r.Start:
+		distance, step = uint64(r.Start)-uint64(r.End), uint64(-(r.Step+1))+1
+	default:
+		return 0
+	}
+
+	return (distance-1)/step + 1
+}
+
+// Records keep the order their fields were defined in, which is the order
+// they are inspected and iterated in. Fields are only bound through Set,
+// which keeps the order and the values together.
 type Record struct {
-	Value map[string]Object
+	values map[string]Object
+	fields []string
+}
+
+func NewRecord() *Record {
+	return &Record{
+		values: map[string]Object{},
+	}
+}
+
+// Binds the field to the value, adding it after the others if it is new.
+func (r *Record) Set(field string, value Object) {
+	if _, ok := r.values[field]; !ok {
+		r.fields = append(r.fields, field)
+	}
+
+	r.values[field] = value
+}
+
+// Returns the value of the field, if the record has it.
+func (r *Record) Get(field string) (Object, bool) {
+	value, ok := r.values[field]
+	return value, ok
+}
+
+// Returns the number of fields.
+func (r *Record) Len() int {
+	return len(r.fields)
+}
+
+// Returns a copy of the record, with its fields in the same order.
+func (r *Record) Copy() *Record {
+	return &Record{
+		values: maps.Clone(r.values),
+		fields: slices.Clone(r.fields),
+	}
+}
+
+// Returns the names of the fields in order.
+func (r *Record) Names() []string {
+	return slices.Clone(r.fields)
 }
 
 func (r *Record) Inspect() string {
 	strs := []string{}
 
-	for field, obj := range r.Value {
-		str := fmt.Sprintf("%s: %s", field, obj.Inspect())
+	for _, field := range r.Names() {
+		str := fmt.Sprintf("%s: %s", field, r.values[field].Inspect())
 		strs = append(strs, str)
 	}
 
@@ -129,10 +342,60 @@ func (r *Record) Inspect() string {
 
 func (r *Record) Type() ObjectType { return RECORD }
 
+// A mutable cell holding a value, which is read with get and replaced
+// with set!. Unlike other values, references are only equal to themselves.
+type Ref struct {
+	Value Object
+}
+
+func (r *Ref) Inspect() string {
+	return fmt.Sprintf("(ref %s)", r.Value.Inspect())
+}
+
+func (r *Ref) Type() ObjectType { return REF }
+
 type Function struct {
-	Parameters  []*ast.Identifier
+	Parameters []*ast.Identifier
+	// The default values of the parameters, as in ast.FunctionLiteral.
+	Defaults []ast.Expression
+	// Set if the last parameter is a rest parameter.
+	Variadic bool
+	// Set for the bodies of loops and the handlers of trys, as in
+	// ast.FunctionLiteral.
+	Block       bool
 	Body        *ast.Scope
 	Environment *Environment
+	// The slots of the function's frame, nil if it has not been resolved.
+	Locals []ast.Identifier
+}
+
+// Returns the number of parameters which can be bound by position,
+// which are all of them but the rest parameter.
+func (f *Function) Positional() int {
+	if f.Variadic {
+		return len(f.Parameters) - 1
+	}
+
+	return len(f.Parameters)
+}
+
+// Returns the number of arguments the function has to be applied to,
+// which is the number of parameters before the first one with a default.
+func (f *Function) Required() int {
+	for i := 0; i < f.Positional(); i++ {
+		if f.Defaults != nil && f.Defaults[i] != nil {
+			return i
+		}
+	}
+
+	return f.Positional()
+}
+
+// Reports whether the function is applied to the number of positional
+// arguments right away, rather than partially, or to more arguments than
+// it takes, with its result applied to the rest.
+func (f *Function) Takes(args int) bool {
+	return args >= f.Required() && (f.Variadic || args <= f.Positional())
 }
 
 func (f *Function) Inspect() string {
@@ -141,8 +404,15 @@ func (f *Function) Inspect() string {
 	sb.WriteString("\\")
 
 	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, string(*p))
+	for i, p := range f.Parameters {
+		switch {
+		case f.Variadic && i == len(f.Parameters)-1:
+			params = append(params, "..."+string(*p))
+		case f.Defaults != nil && f.Defaults[i] != nil:
+			params = append(params, string(*p)+": "+ast.NewPrinter(f.Defaults[i]).String())
+		default:
+			params = append(params, string(*p))
+		}
 	}
 
 	sb.WriteString(strings.Join(params, " "))
@@ -157,10 +427,28 @@ func (f *Function) Inspect() string {
 
 func (f *Function) Type() ObjectType { return FUNCTION }
 
-type BuiltinFunction func(v ast.Visitor, args ...Object) (Object, error)
+// Runtime is the part of an evaluator exposed to builtin functions.
+type Runtime interface {
+	// Applies a function object (user defined or builtin) to the arguments.
+	Apply(fn Object, args ...Object) (Object, error)
+	// The writer standard output of the program goes to.
+	Stdout() io.Writer
+	// The writer standard error of the program goes to.
+	Stderr() io.Writer
+}
+
+type BuiltinFunction func(rt Runtime, args ...Object) (Object, error)
 
 type Builtin struct {
 	Fn BuiltinFunction
+	// Set if the builtin has no effects other than applying the
+	// functions it is given, so applying it to constants can be
+	// done ahead of time.
+	Pure bool
+	// The number of arguments the builtin takes at least. Applying it
+	// to fewer applies it partially, like a function. Zero for builtins
+	// which are always applied to the arguments they are given.
+	Arity int
 }
 
 func MakeBuiltin(fn BuiltinFunction) *Builtin {
@@ -169,6 +457,86 @@ func MakeBuiltin(fn BuiltinFunction) *Builtin {
 	}
 }
 
+func MakePureBuiltin(fn BuiltinFunction) *Builtin {
+	return &Builtin{
+		Fn:   fn,
+		Pure: true,
+	}
+}
+
+// Sets the number of arguments the builtin takes at least, below
+// which applying it applies it partially, and returns the builtin.
+func (b *Builtin) Curried(arity int) *Builtin {
+	b.Arity = arity
+	return b
+}
+
 func (b *Builtin) Type() ObjectType { return BUILTIN }
 
 func (b *Builtin) Inspect() string { return "builtin function" }
+
+// A function or a builtin applied to fewer arguments than it takes. It
+// takes the rest of them when it is applied, and has the type of the
+// function it applies, so it can be used wherever the function can.
+type Partial struct {
+	Function  Object
+	Arguments []Object
+}
+
+// Returns the function partially applied to the arguments,
+// or the function itself if there are no arguments.
+func NewPartial(fn Object, args []Object) Object {
+	if len(args) == 0 {
+		return fn
+	}
+
+	return &Partial{
+		Function:  fn,
+		Arguments: args,
+	}
+}
+
+// Returns the arguments the function is applied to when the partial
+// application is applied to the rest of them.
+func (p *Partial) Complete(rest []Object) []Object {
+	args := make([]Object, 0, len(p.Arguments)+len(rest))
+	args = append(args, p.Arguments...)
+
+	return append(args, rest...)
+}
+
+// Partial applications are inspected as the application which made them.
+func (p *Partial) Inspect() string {
+	strs := []string{p.Function.Inspect()}
+
+	for _, arg := range p.Arguments {
+		strs = append(strs, arg.Inspect())
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(strs, " "))
+}
+
+func (p *Partial) Type() ObjectType { return p.Function.Type() }
+
+// A deferred evaluation of an expression in an environment. Thunks are
+// forced when their value is needed and remember the value afterwards.
+type Thunk struct {
+	Expression  ast.Expression
+	Environment *Environment
+	// nil until the thunk is forced
+	Value Object
+	// set while the thunk is being forced, to detect cycles
+	Forcing bool
+}
+
+func (t *Thunk) Inspect() string {
+	if t.Value != nil {
+		return t.Value.Inspect()
+	}
+
+	p := ast.NewPrinter(t.Expression)
+
+	return "lazy " + p.String()
+}
+
+func (t *Thunk) Type() ObjectType { return THUNK }
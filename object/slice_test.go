@@ -0,0 +1,52 @@
+package object
+
+import "testing"
+
+func TestSliceAppendGrowsAmortized(t *testing.T) {
+	s := NewSlice([]Object{})
+	reallocations := 0
+
+	for i := 0; i < 1000; i++ {
+		backing := s.Value
+		s = s.Append(&Integer{Value: int64(i)})
+
+		if s.Value != backing {
+			reallocations += 1
+		}
+	}
+
+	if s.Length != 1000 || s.Elements()[999].Inspect() != "999" {
+		t.Fatalf("unexpected slice of length %d", s.Length)
+	}
+
+	if reallocations > 10 {
+		t.Errorf("expected at most 10 reallocations, but got %d", reallocations)
+	}
+}
+
+func TestSliceViewsShareElements(t *testing.T) {
+	array := &Array{
+		Value: []Object{&Integer{Value: 1}, &Integer{Value: 2}, &Integer{Value: 3}},
+		Size:  3,
+	}
+
+	view, err := SliceOf(array).Slice(1, 2)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if view.Cap() != 2 {
+		t.Errorf("expected capacity 2, but got %d", view.Cap())
+	}
+
+	view.Append(&Integer{Value: 9})
+
+	if array.Inspect() != "[3: 1 2 9]" {
+		t.Errorf("expected the append to write through, but got %s", array.Inspect())
+	}
+
+	if _, err := view.Slice(0, 3); err == nil {
+		t.Errorf("expected slicing beyond the capacity to fail")
+	}
+}
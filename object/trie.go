@@ -0,0 +1,158 @@
+package object
+
+import "hash/maphash"
+
+// The entries of maps are stored in a hash array mapped trie, which is
+// persistent: changing it copies only the nodes on the path to the entry,
+// so putting a key into a map and deleting one take logarithmic time
+// instead of copying the whole map.
+//
+// A node is either a branch of 32 children, indexed by 5 bits of the hash
+// of the key at each level, or a leaf of the entries whose hashes share
+// the bits on the path to it, which are more than one only once the path
+// is as long as the hash.
+type trie struct {
+	children *[trieWidth]*trie
+	leaves   []*leaf
+}
+
+type leaf struct {
+	key   HashKey
+	hash  uint64
+	entry *MapEntry
+	// the position the key was first added at, which orders the entries
+	position uint64
+}
+
+const (
+	trieBits  = 5
+	trieWidth = 1 << trieBits
+	trieMask  = trieWidth - 1
+	hashBits  = 64
+)
+
+var trieSeed = maphash.MakeSeed()
+
+func hashOf(key HashKey) uint64 {
+	return maphash.String(trieSeed, string(key))
+}
+
+func (t *trie) get(key HashKey, hash uint64, shift uint) (*leaf, bool) {
+	for t != nil {
+		if t.children == nil {
+			for _, l := range t.leaves {
+				if l.key == key {
+					return l, true
+				}
+			}
+
+			return nil, false
+		}
+
+		t = t.children[(hash>>shift)&trieMask]
+		shift += trieBits
+	}
+
+	return nil, false
+}
+
+// Returns a trie with the leaf in it, replacing the leaf of the same key,
+// and whether the key is new. The trie itself is left as it is.
+func (t *trie) put(l *leaf, shift uint) (*trie, bool) {
+	if t == nil {
+		return &trie{leaves: []*leaf{l}}, true
+	}
+
+	if t.children == nil {
+		for i, existing := range t.leaves {
+			if existing.key == l.key {
+				leaves := append([]*leaf{}, t.leaves...)
+				leaves[i] = l
+				return &trie{leaves: leaves}, false
+			}
+		}
+
+		// keys whose hashes are equal share the leaf
+		if shift >= hashBits || t.leaves[0].hash == l.hash {
+			return &trie{leaves: append(append([]*leaf{}, t.leaves...), l)}, true
+		}
+
+		// otherwise the leaf becomes a branch, which tells them apart
+		branch := &trie{children: &[trieWidth]*trie{}}
+
+		for _, existing := range t.leaves {
+			branch, _ = branch.put(existing, shift)
+		}
+
+		return branch.put(l, shift)
+	}
+
+	index := (l.hash >> shift) & trieMask
+	child, added := t.children[index].put(l, shift+trieBits)
+
+	children := *t.children
+	children[index] = child
+
+	return &trie{children: &children}, added
+}
+
+// Returns a trie without the key, and whether it was in it. The trie
+// itself is left as it is.
+func (t *trie) delete(key HashKey, hash uint64, shift uint) (*trie, bool) {
+	if t == nil {
+		return nil, false
+	}
+
+	if t.children == nil {
+		for i, existing := range t.leaves {
+			if existing.key != key {
+				continue
+			}
+
+			if len(t.leaves) == 1 {
+				return nil, true
+			}
+
+			leaves := append(append([]*leaf{}, t.leaves[:i]...), t.leaves[i+1:]...)
+
+			return &trie{leaves: leaves}, true
+		}
+
+		return t, false
+	}
+
+	index := (hash >> shift) & trieMask
+	child, deleted := t.children[index].delete(key, hash, shift+trieBits)
+
+	if !deleted {
+		return t, false
+	}
+
+	children := *t.children
+	children[index] = child
+
+	for _, c := range children {
+		if c != nil {
+			return &trie{children: &children}, true
+		}
+	}
+
+	return nil, true
+}
+
+// Calls the function with each leaf of the trie, in no particular order.
+func (t *trie) each(fn func(l *leaf)) {
+	if t == nil {
+		return
+	}
+
+	for _, l := range t.leaves {
+		fn(l)
+	}
+
+	if t.children != nil {
+		for _, c := range t.children {
+			c.each(fn)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package object
+
+import "fmt"
+
+// The result of something which can fail, either an Ok holding the value
+// it succeeded with, or an Err holding what went wrong, often an error.
+type Result struct {
+	Ok    bool
+	Value Object
+}
+
+func Ok(value Object) *Result {
+	return &Result{Ok: true, Value: value}
+}
+
+func Err(value Object) *Result {
+	return &Result{Ok: false, Value: value}
+}
+
+func (r *Result) Inspect() string {
+	if r.Ok {
+		return fmt.Sprintf("(Ok %s)", r.Value.Inspect())
+	}
+
+	return fmt.Sprintf("(Err %s)", r.Value.Inspect())
+}
+
+func (r *Result) Type() ObjectType { return RESULT }
+
+// A value which may be missing, either a Some holding it or None.
+type Option struct {
+	// nil for None
+	Value Object
+}
+
+var NONE = &Option{}
+
+func Some(value Object) *Option {
+	return &Option{Value: value}
+}
+
+func (o *Option) Inspect() string {
+	if o.Value == nil {
+		return "None"
+	}
+
+	return fmt.Sprintf("(Some %s)", o.Value.Inspect())
+}
+
+func (o *Option) Type() ObjectType { return OPTION }
@@ -0,0 +1,399 @@
+package optimizer
+
+import (
+	"slices"
+
+	"raiton/ast"
+)
+
+// walker calls visit with every node of the tree, parents before children.
+// Children are skipped if visit returns false.
+type walker struct {
+	visit func(node ast.Node) bool
+}
+
+func walk(node ast.Node, visit func(node ast.Node) bool) {
+	node.Accept(&walker{visit: visit})
+}
+
+func (w *walker) walkAll(exprs []ast.Expression) {
+	for _, expr := range exprs {
+		expr.Accept(w)
+	}
+}
+
+// Counts the nodes of the tree, as a measure of the cost of copying it.
+func size(node ast.Node) int {
+	n := 0
+
+	walk(node, func(_ ast.Node) bool {
+		n += 1
+		return true
+	})
+
+	return n
+}
+
+// Collects the names bound anywhere in the tree, by definitions and parameters.
+func binders(node ast.Node) map[string]bool {
+	names := map[string]bool{}
+
+	walk(node, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Definition:
+			names[string(n.Identifier)] = true
+		case *ast.FunctionLiteral:
+			for _, p := range n.Parameters {
+				names[string(*p)] = true
+			}
+		}
+
+		return true
+	})
+
+	return names
+}
+
+// Counts the references to each name in the tree, regardless of what they
+// are bound to, so a name which is not referenced is certainly unused.
+func references(node ast.Node) map[string]int {
+	counts := map[string]int{}
+
+	walk(node, func(node ast.Node) bool {
+		if name, ok := referencedName(node); ok {
+			counts[name] += 1
+		}
+
+		return true
+	})
+
+	return counts
+}
+
+// Counts the definitions of the name made in the current frame by the tree,
+// that is outside of the bodies of nested functions.
+func definitions(node ast.Node, name string) int {
+	n := 0
+
+	walk(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Definition:
+			if string(node.Identifier) == name {
+				n += 1
+			}
+		case *ast.FunctionLiteral:
+			return false
+		}
+
+		return true
+	})
+
+	return n
+}
+
+// Returns the name an identifier or selector looks up.
+func referencedName(node ast.Node) (string, bool) {
+	switch n := node.(type) {
+	case *ast.Identifier:
+		return string(*n), true
+	case *ast.Selector:
+		if len(n.Items) > 0 && n.Items[0].Identifier != nil {
+			return string(*n.Items[0].Identifier), true
+		}
+	}
+
+	return "", false
+}
+
+// Reports whether evaluating the expression can neither fail nor have
+// effects, so it can be dropped if its value is not used.
+func removable(expr ast.Expression) bool {
+	ok := true
+
+	walk(expr, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FunctionLiteral, *ast.Lazy:
+			// evaluating them creates a closure or a thunk, but runs nothing
+			return false
+		case *ast.ArrayLiteral:
+			ok = ok && uint64(len(n.Elements)) == n.Size
+			return ok
+		case *ast.RecordLiteral:
+			// updates and spreads fail on values which aren't records
+			ok = ok && n.Base == nil && !slices.ContainsFunc(n.Fields, func(field *ast.RecordField) bool {
+				return field.Spread
+			})
+			return ok
+		case *ast.SliceLiteral, *ast.TupleLiteral, *ast.IntegerLiteral, *ast.BigIntegerLiteral,
+			*ast.FloatLiteral, *ast.StringLiteral, *ast.CharacterLiteral, *ast.BooleanLiteral:
+			return true
+		default:
+			ok = false
+			return false
+		}
+	})
+
+	return ok
+}
+
+// Reports whether a ? in the body of the function returns from it, rather
+// than from a function nested in it. Loop bodies and handlers are not
+// returned from, so a ? in them returns from the function around them.
+func returns(fn *ast.FunctionLiteral) bool {
+	found := false
+
+	walk(fn.Body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Propagate:
+			found = true
+		case *ast.FunctionLiteral:
+			return n.Block
+		}
+
+		return !found
+	})
+
+	return found
+}
+
+/*** Visitor Methods ***/
+
+func (w *walker) VisitScope(n *ast.Scope) error {
+	if !w.visit(n) {
+		return nil
+	}
+
+	for _, item := range n.Items {
+		item.Accept(w)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitDefinition(n *ast.Definition) error {
+	if w.visit(n) {
+		n.Expression.Accept(w)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitIdentifier(n *ast.Identifier) error {
+	w.visit(n)
+	return nil
+}
+
+func (w *walker) VisitSelector(n *ast.Selector) error {
+	if w.visit(n) {
+		for _, item := range n.Items {
+			item.Accept(w)
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) VisitSelectorItem(n *ast.SelectorItem) error {
+	if w.visit(n) {
+		if n.Expression != nil {
+			n.Expression.Accept(w)
+		}
+
+		if n.End != nil {
+			n.End.Accept(w)
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) VisitApplication(n *ast.Application) error {
+	if w.visit(n) {
+		w.walkAll(n.Arguments)
+
+		for _, a := range n.Named {
+			a.Expression.Accept(w)
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) VisitConditional(n *ast.Conditional) error {
+	if w.visit(n) {
+		n.Condition.Accept(w)
+		n.Consequence.Accept(w)
+		n.Alternative.Accept(w)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitLazy(n *ast.Lazy) error {
+	if w.visit(n) {
+		n.Expression.Accept(w)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitFor(n *ast.For) error {
+	if w.visit(n) {
+		n.Iterable.Accept(w)
+		n.Body.Accept(w)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitBreak(n *ast.Break) error {
+	w.visit(n)
+	return nil
+}
+
+func (w *walker) VisitContinue(n *ast.Continue) error {
+	w.visit(n)
+	return nil
+}
+
+func (w *walker) VisitPropagate(n *ast.Propagate) error {
+	if w.visit(n) {
+		n.Expression.Accept(w)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitAssignment(n *ast.Assignment) error {
+	if w.visit(n) {
+		n.Target.Accept(w)
+		n.Value.Accept(w)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitComposition(n *ast.Composition) error {
+	if w.visit(n) {
+		w.walkAll(n.Functions)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitTry(n *ast.Try) error {
+	if w.visit(n) {
+		n.Body.Accept(w)
+
+		if n.Handler != nil {
+			n.Handler.Accept(w)
+		}
+
+		if n.Finalizer != nil {
+			n.Finalizer.Accept(w)
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) VisitFunction(n *ast.FunctionLiteral) error {
+	if w.visit(n) {
+		for _, d := range n.Defaults {
+			if d != nil {
+				d.Accept(w)
+			}
+		}
+
+		n.Body.Accept(w)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitRecord(n *ast.RecordLiteral) error {
+	if w.visit(n) {
+		if n.Base != nil {
+			n.Base.Accept(w)
+		}
+
+		for _, field := range n.Fields {
+			field.Expression.Accept(w)
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) VisitMap(n *ast.MapLiteral) error {
+	if w.visit(n) {
+		for i, key := range n.Keys {
+			key.Accept(w)
+			n.Values[i].Accept(w)
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) VisitArray(n *ast.ArrayLiteral) error {
+	if w.visit(n) {
+		w.walkAll(n.Elements)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitSlice(n *ast.SliceLiteral) error {
+	if w.visit(n) {
+		w.walkAll(n.Elements)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitTuple(n *ast.TupleLiteral) error {
+	if w.visit(n) {
+		w.walkAll(n.Elements)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitUnpack(n *ast.Unpack) error {
+	if w.visit(n) {
+		n.Tuple.Accept(w)
+	}
+
+	return nil
+}
+
+func (w *walker) VisitInteger(n *ast.IntegerLiteral) error {
+	w.visit(n)
+	return nil
+}
+
+func (w *walker) VisitBigInteger(n *ast.BigIntegerLiteral) error {
+	w.visit(n)
+	return nil
+}
+
+func (w *walker) VisitFloat(n *ast.FloatLiteral) error {
+	w.visit(n)
+	return nil
+}
+
+func (w *walker) VisitString(n *ast.StringLiteral) error {
+	w.visit(n)
+	return nil
+}
+
+func (w *walker) VisitCharacter(n *ast.CharacterLiteral) error {
+	w.visit(n)
+	return nil
+}
+
+func (w *walker) VisitBoolean(n *ast.BooleanLiteral) error {
+	w.visit(n)
+	return nil
+}
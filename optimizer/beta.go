@@ -0,0 +1,201 @@
+package optimizer
+
+import (
+	"raiton/ast"
+)
+
+// reducer substitutes the arguments of immediately applied function literals
+// for their parameters, when the body is a single expression. An argument is
+// only substituted if it can't have effects, since it may end up evaluated
+// any number of times, and if none of its names would be captured by the
+// bindings of the body.
+type reducer struct {
+	rewriter
+}
+
+// Reports whether the argument can be substituted
+// for a parameter referenced the given number of times.
+func substitutable(arg ast.Expression, uses int) bool {
+	switch arg.(type) {
+	case *ast.IntegerLiteral, *ast.BigIntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral,
+		*ast.CharacterLiteral, *ast.BooleanLiteral:
+		return true
+	case *ast.Identifier:
+		// a lookup which is not made anymore can't fail either
+		return uses > 0
+	case *ast.Selector:
+		// and neither can the keys it computes, as long as they
+		// can be substituted themselves
+		ok := uses > 0
+
+		walk(arg, func(node ast.Node) bool {
+			if item, isItem := node.(*ast.SelectorItem); isItem && item.Expression != nil {
+				ok = ok && substitutable(item.Expression, uses)
+			}
+
+			return ok
+		})
+
+		return ok
+	default:
+		// copying anything else might cost more than binding it
+		return uses == 1 && removable(arg)
+	}
+}
+
+// Reports whether the expression makes definitions in
+// the frame it is evaluated in, outside of nested functions.
+func definesNames(expr ast.Expression) bool {
+	defines := false
+
+	walk(expr, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.Definition:
+			defines = true
+		case *ast.FunctionLiteral:
+			return false
+		}
+
+		return !defines
+	})
+
+	return defines
+}
+
+/*** Visitor Methods ***/
+
+func (r *reducer) VisitApplication(n *ast.Application) error {
+	if err := r.rewriter.VisitApplication(n); err != nil {
+		return err
+	}
+
+	app := r.result.(*ast.Application)
+	fn, ok := app.Arguments[0].(*ast.FunctionLiteral)
+
+	if !ok || !fn.Fixed() || len(app.Named) > 0 || len(fn.Parameters) != len(app.Arguments)-1 {
+		return nil
+	}
+
+	body, ok := fn.Body.Expression()
+
+	if !ok {
+		return nil
+	}
+
+	// a ? would return from the function around the application instead
+	if returns(fn) {
+		return nil
+	}
+
+	// definitions of nested scopes would end up in the enclosing frame
+	if definesNames(body) {
+		return nil
+	}
+
+	bound := binders(body)
+	uses := free(body)
+	substitutions := map[string]ast.Expression{}
+
+	for i, p := range fn.Parameters {
+		arg := app.Arguments[i+1]
+		name := string(*p)
+
+		if bound[name] || !substitutable(arg, uses[name]) {
+			return nil
+		}
+
+		for n := range free(arg) {
+			if bound[n] {
+				return nil
+			}
+		}
+
+		substitutions[name] = arg
+	}
+
+	s := &substituter{substitutions: substitutions}
+	s.self = s
+
+	expr, err := s.rewrite(body)
+
+	if err != nil || s.failed {
+		return err
+	}
+
+	r.result = expr
+
+	return nil
+}
+
+// substituter replaces references to names with copies of expressions.
+type substituter struct {
+	rewriter
+	substitutions map[string]ast.Expression
+	// set if a selector selects from a name whose substitute
+	// can't be selected from, like a literal
+	failed bool
+}
+
+func (s *substituter) substitute(name string) (ast.Expression, bool, error) {
+	expr, ok := s.substitutions[name]
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	node, err := copyTree(expr)
+
+	return node, true, err
+}
+
+func (s *substituter) VisitIdentifier(n *ast.Identifier) error {
+	expr, ok, err := s.substitute(string(*n))
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return s.rewriter.VisitIdentifier(n)
+	}
+
+	s.result = expr
+
+	return nil
+}
+
+func (s *substituter) VisitSelector(n *ast.Selector) error {
+	name, _ := referencedName(n)
+	expr, ok, err := s.substitute(name)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return s.rewriter.VisitSelector(n)
+	}
+
+	rest, err := s.rewriteItems(n.Items[1:])
+
+	if err != nil {
+		return err
+	}
+
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		items := append([]*ast.SelectorItem{ast.NewIdentifierSelector(expr)}, rest...)
+		s.result = ast.NewSelector(items...)
+	case *ast.Selector:
+		items := append(expr.Items[:len(expr.Items):len(expr.Items)], rest...)
+		s.result = ast.NewSelector(items...)
+	default:
+		if len(n.Items) > 1 {
+			s.failed = true
+		}
+
+		s.result = expr
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package optimizer
+
+import (
+	"raiton/ast"
+)
+
+// eliminator removes the definitions of function bodies which are referenced
+// nowhere in the body, and whose expressions can't have effects. Definitions
+// outside of functions are kept, as programs evaluated later may refer to them.
+type eliminator struct {
+	rewriter
+}
+
+// Removes the unused definitions of the scope, until all are used.
+func prune(s *ast.Scope) {
+	for {
+		removed := false
+
+		for i, item := range s.Items {
+			d, ok := item.(*ast.Definition)
+
+			// a scope without expressions evaluates to its last definition
+			if !ok || i == s.Result() {
+				continue
+			}
+
+			if !removable(d.Expression) || referenced(s, i) {
+				continue
+			}
+
+			s.Items = append(s.Items[:i], s.Items[i+1:]...)
+			removed = true
+
+			break
+		}
+
+		if !removed {
+			return
+		}
+	}
+}
+
+// Reports whether the name of the definition at index i of the scope
+// is referenced anywhere in the scope, but in its own expression.
+func referenced(s *ast.Scope, i int) bool {
+	name := string(s.Items[i].(*ast.Definition).Identifier)
+
+	for j, item := range s.Items {
+		if j != i && references(item)[name] > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*** Visitor Methods ***/
+
+func (e *eliminator) VisitFunction(n *ast.FunctionLiteral) error {
+	if err := e.rewriter.VisitFunction(n); err != nil {
+		return err
+	}
+
+	prune(e.result.(*ast.FunctionLiteral).Body)
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package optimizer
+
+import (
+	"io"
+	"strconv"
+
+	"raiton/ast"
+	"raiton/evaluator"
+	"raiton/object"
+)
+
+// Folding gives up on expressions which take longer than this to evaluate,
+// leaving them to be evaluated when the program runs.
+const (
+	foldSteps = 10000
+	foldDepth = 100
+)
+
+// folder evaluates applications which only refer to pure builtins and to
+// names bound within themselves, and replaces them with literals of their
+// values. Expressions which fail, or whose values have no literals, like
+// functions, are left to be evaluated when the program runs.
+type folder struct {
+	rewriter
+	pure map[string]object.Object
+}
+
+// Reports whether the expression only refers to pure builtins.
+func (f *folder) constant(expr ast.Expression) bool {
+	for name := range free(expr) {
+		if _, ok := f.pure[name]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *folder) evaluate(expr ast.Expression) (ast.Expression, bool) {
+	e := evaluator.New(
+		object.NewEnvironment(),
+		evaluator.WithBuiltins(f.pure),
+		evaluator.WithStdout(io.Discard),
+		evaluator.WithStderr(io.Discard),
+		evaluator.WithMaxSteps(foldSteps),
+		evaluator.WithMaxDepth(foldDepth),
+		evaluator.WithDynamicLookup(),
+	)
+
+	obj, err := e.Evaluate(expr)
+
+	if err != nil {
+		return nil, false
+	}
+
+	return literal(obj)
+}
+
+// Returns a literal which evaluates to a copy of the object.
+func literal(obj object.Object) (ast.Expression, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return ast.NewIntegerLiteral(obj.Value), true
+	case *object.BigInt:
+		return ast.NewBigIntegerLiteral(obj.Value), true
+	case *object.Float:
+		return ast.NewFloatLiteral(obj.Value), true
+	case *object.String:
+		return ast.NewStringLiteral(obj.Value), true
+	case *object.Character:
+		return ast.NewCharacterLiteral(obj.Value), true
+	case *object.Boolean:
+		return ast.NewBooleanLiteral(strconv.FormatBool(obj.Value)), true
+	case *object.Array:
+		elements, ok := literals(obj.Value)
+
+		if !ok {
+			return nil, false
+		}
+
+		return ast.NewArrayLiteral(obj.Size, elements...), true
+	case *object.Slice:
+		elements, ok := literals(obj.Elements())
+
+		if !ok {
+			return nil, false
+		}
+
+		return ast.NewSliceLiteral(elements...), true
+	case *object.Tuple:
+		elements, ok := literals(obj.Elements)
+
+		if !ok {
+			return nil, false
+		}
+
+		return ast.NewTupleLiteral(elements...), true
+	case *object.Map:
+		m := &ast.MapLiteral{}
+
+		for _, entry := range obj.Entries() {
+			key, ok := literal(entry.Key)
+
+			if !ok {
+				return nil, false
+			}
+
+			value, ok := literal(entry.Value)
+
+			if !ok {
+				return nil, false
+			}
+
+			m.Keys = append(m.Keys, key)
+			m.Values = append(m.Values, value)
+		}
+
+		return m, true
+	case *object.Record:
+		fields := make([]*ast.RecordField, obj.Len())
+
+		for i, name := range obj.Names() {
+			value, _ := obj.Get(name)
+			expr, ok := literal(value)
+
+			if !ok {
+				return nil, false
+			}
+
+			fields[i] = &ast.RecordField{
+				Identifier: ast.Identifier(name),
+				Expression: expr,
+			}
+		}
+
+		return &ast.RecordLiteral{Fields: fields}, true
+	default:
+		return nil, false
+	}
+}
+
+func literals(objs []object.Object) ([]ast.Expression, bool) {
+	exprs := make([]ast.Expression, len(objs))
+
+	for i, obj := range objs {
+		expr, ok := literal(obj)
+
+		if !ok {
+			return nil, false
+		}
+
+		exprs[i] = expr
+	}
+
+	return exprs, true
+}
+
+/*** Visitor Methods ***/
+
+func (f *folder) VisitApplication(n *ast.Application) error {
+	if err := f.rewriter.VisitApplication(n); err != nil {
+		return err
+	}
+
+	app := f.result.(*ast.Application)
+
+	if !f.constant(app) {
+		return nil
+	}
+
+	if expr, ok := f.evaluate(app); ok {
+		f.result = expr
+	}
+
+	return nil
+}
+
+func (f *folder) VisitConditional(n *ast.Conditional) error {
+	if err := f.rewriter.VisitConditional(n); err != nil {
+		return err
+	}
+
+	cond := f.result.(*ast.Conditional)
+
+	value, ok := cond.Condition.(*ast.BooleanLiteral)
+
+	if !ok {
+		return nil
+	}
+
+	var branch ast.Expression = cond.Consequence
+
+	if string(*value) != "true" {
+		branch = cond.Alternative
+	}
+
+	switch b := branch.(type) {
+	case *ast.Conditional:
+		f.result = b
+	case *ast.Scope:
+		// branches defining names are kept, since the
+		// definitions are made in the enclosing scope
+		if expr, ok := b.Expression(); ok {
+			f.result = expr
+		}
+	}
+
+	return nil
+}
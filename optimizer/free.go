@@ -0,0 +1,237 @@
+package optimizer
+
+import (
+	"raiton/ast"
+)
+
+// freeNames collects the names an expression refers to without binding them
+// itself. Definitions bind their name from their own expression on, so a name
+// referenced before it is defined in the same scope is free, as it is looked
+// up in the enclosing scopes when evaluated.
+type freeNames struct {
+	bound []map[string]bool
+	free  map[string]int
+}
+
+// Returns the free names of the expression with the number of their references.
+func free(node ast.Node) map[string]int {
+	f := &freeNames{
+		bound: []map[string]bool{{}},
+		free:  map[string]int{},
+	}
+
+	node.Accept(f)
+
+	return f.free
+}
+
+func (f *freeNames) isBound(name string) bool {
+	for _, names := range f.bound {
+		if names[name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *freeNames) reference(name string) {
+	if !f.isBound(name) {
+		f.free[name] += 1
+	}
+}
+
+func (f *freeNames) acceptAll(exprs []ast.Expression) {
+	for _, expr := range exprs {
+		expr.Accept(f)
+	}
+}
+
+/*** Visitor Methods ***/
+
+func (f *freeNames) VisitScope(n *ast.Scope) error {
+	// scopes share the frame they are evaluated in,
+	// so their definitions stay bound after them
+	for _, item := range n.Items {
+		item.Accept(f)
+	}
+
+	return nil
+}
+
+func (f *freeNames) VisitDefinition(n *ast.Definition) error {
+	f.bound[len(f.bound)-1][string(n.Identifier)] = true
+	return n.Expression.Accept(f)
+}
+
+func (f *freeNames) VisitIdentifier(n *ast.Identifier) error {
+	f.reference(string(*n))
+	return nil
+}
+
+func (f *freeNames) VisitSelector(n *ast.Selector) error {
+	if name, ok := referencedName(n); ok {
+		f.reference(name)
+	}
+
+	for _, item := range n.Items {
+		item.Accept(f)
+	}
+
+	return nil
+}
+
+func (f *freeNames) VisitSelectorItem(n *ast.SelectorItem) error {
+	if n.Expression != nil {
+		n.Expression.Accept(f)
+	}
+
+	if n.End != nil {
+		n.End.Accept(f)
+	}
+
+	return nil
+}
+
+func (f *freeNames) VisitApplication(n *ast.Application) error {
+	f.acceptAll(n.Arguments)
+
+	for _, a := range n.Named {
+		a.Expression.Accept(f)
+	}
+
+	return nil
+}
+
+func (f *freeNames) VisitConditional(n *ast.Conditional) error {
+	n.Condition.Accept(f)
+	n.Consequence.Accept(f)
+	n.Alternative.Accept(f)
+
+	return nil
+}
+
+func (f *freeNames) VisitLazy(n *ast.Lazy) error {
+	return n.Expression.Accept(f)
+}
+
+func (f *freeNames) VisitFor(n *ast.For) error {
+	n.Iterable.Accept(f)
+	return n.Body.Accept(f)
+}
+
+func (f *freeNames) VisitBreak(n *ast.Break) error {
+	return nil
+}
+
+func (f *freeNames) VisitContinue(n *ast.Continue) error {
+	return nil
+}
+
+func (f *freeNames) VisitPropagate(n *ast.Propagate) error {
+	return n.Expression.Accept(f)
+}
+
+func (f *freeNames) VisitAssignment(n *ast.Assignment) error {
+	n.Target.Accept(f)
+	return n.Value.Accept(f)
+}
+
+func (f *freeNames) VisitComposition(n *ast.Composition) error {
+	f.acceptAll(n.Functions)
+	return nil
+}
+
+func (f *freeNames) VisitTry(n *ast.Try) error {
+	n.Body.Accept(f)
+
+	if n.Handler != nil {
+		n.Handler.Accept(f)
+	}
+
+	if n.Finalizer != nil {
+		n.Finalizer.Accept(f)
+	}
+
+	return nil
+}
+
+func (f *freeNames) VisitFunction(n *ast.FunctionLiteral) error {
+	params := map[string]bool{}
+
+	for _, p := range n.Parameters {
+		params[string(*p)] = true
+	}
+
+	f.bound = append(f.bound, params)
+	defer func() { f.bound = f.bound[:len(f.bound)-1] }()
+
+	for _, d := range n.Defaults {
+		if d != nil {
+			d.Accept(f)
+		}
+	}
+
+	return n.Body.Accept(f)
+}
+
+func (f *freeNames) VisitRecord(n *ast.RecordLiteral) error {
+	if n.Base != nil {
+		n.Base.Accept(f)
+	}
+
+	for _, field := range n.Fields {
+		field.Expression.Accept(f)
+	}
+
+	return nil
+}
+
+func (f *freeNames) VisitMap(n *ast.MapLiteral) error {
+	f.acceptAll(n.Keys)
+	f.acceptAll(n.Values)
+	return nil
+}
+
+func (f *freeNames) VisitArray(n *ast.ArrayLiteral) error {
+	f.acceptAll(n.Elements)
+	return nil
+}
+
+func (f *freeNames) VisitSlice(n *ast.SliceLiteral) error {
+	f.acceptAll(n.Elements)
+	return nil
+}
+
+func (f *freeNames) VisitTuple(n *ast.TupleLiteral) error {
+	f.acceptAll(n.Elements)
+	return nil
+}
+
+func (f *freeNames) VisitUnpack(n *ast.Unpack) error {
+	return n.Tuple.Accept(f)
+}
+
+func (f *freeNames) VisitInteger(n *ast.IntegerLiteral) error {
+	return nil
+}
+
+func (f *freeNames) VisitBigInteger(n *ast.BigIntegerLiteral) error {
+	return nil
+}
+
+func (f *freeNames) VisitFloat(n *ast.FloatLiteral) error {
+	return nil
+}
+
+func (f *freeNames) VisitString(n *ast.StringLiteral) error {
+	return nil
+}
+
+func (f *freeNames) VisitCharacter(n *ast.CharacterLiteral) error {
+	return nil
+}
+
+func (f *freeNames) VisitBoolean(n *ast.BooleanLiteral) error {
+	return nil
+}
@@ -0,0 +1,215 @@
+package optimizer
+
+import (
+	"raiton/ast"
+)
+
+// Functions with more nodes than this are not inlined.
+const maxInlineSize = 32
+
+// inliner replaces applications of small, non-recursive functions, which are
+// bound by definitions, with applications of copies of their literals, which
+// are then reduced by the beta pass. A function is only inlined where its name
+// and the names it refers to are bound to the same definitions as where it is
+// defined, and if it is defined exactly once in its frame.
+type inliner struct {
+	rewriter
+	frames []*frame
+	// the number of function literals and lazy expressions
+	// enclosing the node being rewritten
+	deferred int
+}
+
+// The names bound in a function's frame, or in the frame of the program.
+type frame struct {
+	// the candidate bound to each name, nil if the name can't be inlined
+	names map[string]*candidate
+	root  ast.Node
+	// set for the program's frame, whose definitions can be redefined by
+	// programs evaluated later, so they are only inlined into expressions
+	// evaluated right away
+	top bool
+}
+
+type candidate struct {
+	function *ast.FunctionLiteral
+	frame    *frame
+	deferred int
+	// the frames the free names of the function are bound in
+	free map[string]*frame
+}
+
+func (i *inliner) pushFrame(root ast.Node, top bool) *frame {
+	f := &frame{
+		names: map[string]*candidate{},
+		root:  root,
+		top:   top,
+	}
+
+	i.frames = append(i.frames, f)
+
+	return f
+}
+
+func (i *inliner) popFrame() {
+	i.frames = i.frames[:len(i.frames)-1]
+}
+
+func (i *inliner) current() *frame {
+	return i.frames[len(i.frames)-1]
+}
+
+// Finds the frame the name is bound in, or nil if
+// it is bound outside of the program, or not at all.
+func (i *inliner) resolve(name string) (*frame, *candidate) {
+	for j := len(i.frames) - 1; j >= 0; j-- {
+		if c, ok := i.frames[j].names[name]; ok {
+			return i.frames[j], c
+		}
+	}
+
+	return nil, nil
+}
+
+// Returns the candidate for inlining the definition,
+// or nil if its expression can't be inlined.
+func (i *inliner) candidate(d *ast.Definition) *candidate {
+	fn, ok := d.Expression.(*ast.FunctionLiteral)
+
+	if !ok || size(fn) > maxInlineSize {
+		return nil
+	}
+
+	name := string(d.Identifier)
+	f := i.current()
+	names := free(fn)
+
+	if names[name] > 0 || definitions(f.root, name) != 1 {
+		return nil
+	}
+
+	c := &candidate{
+		function: fn,
+		frame:    f,
+		deferred: i.deferred,
+		free:     map[string]*frame{},
+	}
+
+	for n := range names {
+		c.free[n], _ = i.resolve(n)
+	}
+
+	return c
+}
+
+// Reports whether the candidate can be inlined where the
+// node being rewritten is, with the given number of arguments.
+func (i *inliner) inlinable(c *candidate, arguments int) bool {
+	if !c.function.Fixed() || len(c.function.Parameters) != arguments {
+		return false
+	}
+
+	if c.frame.top && i.deferred > c.deferred {
+		return false
+	}
+
+	for name, f := range c.free {
+		if resolved, _ := i.resolve(name); resolved != f {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*** Visitor Methods ***/
+
+func (i *inliner) VisitScope(n *ast.Scope) error {
+	f := i.current()
+
+	// definitions shadow enclosing bindings from the start of
+	// the scope, but are only inlined after they are defined
+	for _, item := range n.Items {
+		if d, ok := item.(*ast.Definition); ok {
+			f.names[string(d.Identifier)] = nil
+		}
+	}
+
+	scope := &ast.Scope{
+		Items: make([]ast.Node, len(n.Items)),
+	}
+
+	for j, item := range n.Items {
+		rewritten, err := i.rewriteScopeItem(item)
+
+		if err != nil {
+			return err
+		}
+
+		scope.Items[j] = rewritten
+
+		if def, ok := rewritten.(*ast.Definition); ok {
+			f.names[string(def.Identifier)] = i.candidate(def)
+		}
+	}
+
+	i.result = scope
+
+	return nil
+}
+
+func (i *inliner) VisitApplication(n *ast.Application) error {
+	if err := i.rewriter.VisitApplication(n); err != nil {
+		return err
+	}
+
+	app := i.result.(*ast.Application)
+	name, ok := referencedName(app.Arguments[0])
+
+	if !ok || len(app.Named) > 0 {
+		return nil
+	}
+
+	if selector, ok := app.Arguments[0].(*ast.Selector); ok && len(selector.Items) > 1 {
+		return nil
+	}
+
+	_, c := i.resolve(name)
+
+	if c == nil || !i.inlinable(c, len(app.Arguments)-1) {
+		return nil
+	}
+
+	fn, err := copyTree(c.function)
+
+	if err != nil {
+		return err
+	}
+
+	app.Arguments[0] = fn
+
+	return nil
+}
+
+func (i *inliner) VisitLazy(n *ast.Lazy) error {
+	i.deferred += 1
+	defer func() { i.deferred -= 1 }()
+
+	return i.rewriter.VisitLazy(n)
+}
+
+func (i *inliner) VisitFunction(n *ast.FunctionLiteral) error {
+	f := i.pushFrame(n.Body, false)
+	i.deferred += 1
+
+	defer func() {
+		i.popFrame()
+		i.deferred -= 1
+	}()
+
+	for _, p := range n.Parameters {
+		f.names[string(*p)] = nil
+	}
+
+	return i.rewriter.VisitFunction(n)
+}
@@ -0,0 +1,207 @@
+// Package optimizer rewrites programs into equivalent ones which take less
+// work to evaluate. It runs on the syntax tree, before any of the backends,
+// as a series of passes which can be enabled separately.
+package optimizer
+
+import (
+	"fmt"
+
+	"raiton/ast"
+	"raiton/evaluator"
+	"raiton/object"
+)
+
+// The names of the passes, in the order they run in.
+const (
+	// replaces applications of small, non-recursive functions
+	// with immediately applied copies of their literals
+	INLINE = "inline"
+	// substitutes the arguments of immediately applied function
+	// literals into their bodies
+	BETA = "beta"
+	// evaluates applications of pure builtins to constants ahead of time,
+	// and picks the branch of conditionals with constant conditions
+	FOLD = "fold"
+	// removes unused definitions which have no effects from function bodies
+	DEAD = "dead"
+)
+
+var Passes = []string{INLINE, BETA, FOLD, DEAD}
+
+// The passes are run until the program stops changing, but at most this often.
+const maxRounds = 4
+
+type Optimizer struct {
+	passes   map[string]bool
+	builtins map[string]object.Object
+	defined  func(name string) bool
+	dump     func(pass string, node ast.Node)
+}
+
+type Option func(o *Optimizer)
+
+// Enables only the given passes. An unknown name fails the optimization.
+func WithPasses(passes ...string) Option {
+	return func(o *Optimizer) {
+		o.passes = map[string]bool{}
+
+		for _, pass := range passes {
+			o.passes[pass] = true
+		}
+	}
+}
+
+// Sets the builtins the program is going to be evaluated with. Only the pure
+// ones are folded, and only where the program doesn't shadow them.
+func WithBuiltins(builtins map[string]object.Object) Option {
+	return func(o *Optimizer) {
+		o.builtins = builtins
+	}
+}
+
+// Sets the function which reports whether a name is already bound in
+// the environment the program is going to be evaluated in, in which
+// case it shadows the builtin with the same name.
+func WithDefined(defined func(name string) bool) Option {
+	return func(o *Optimizer) {
+		o.defined = defined
+	}
+}
+
+// Sets a function called with the program after every pass that changes it.
+func WithDump(dump func(pass string, node ast.Node)) Option {
+	return func(o *Optimizer) {
+		o.dump = dump
+	}
+}
+
+// Creates an Optimizer running all passes, which folds the default builtins.
+func New(opts ...Option) Optimizer {
+	o := Optimizer{
+		passes:   map[string]bool{},
+		builtins: evaluator.DefaultBuiltins(),
+		defined: func(_ string) bool {
+			return false
+		},
+	}
+
+	for _, pass := range Passes {
+		o.passes[pass] = true
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// Returns an optimized copy of the node, leaving the node itself untouched.
+func (o *Optimizer) Optimize(node ast.Node) (ast.Node, error) {
+	for pass := range o.passes {
+		if !isPass(pass) {
+			return nil, fmt.Errorf("unknown optimization pass '%s'", pass)
+		}
+	}
+
+	printed := ast.NewPrinter(node).String()
+
+	for round := 0; round < maxRounds; round++ {
+		changed := false
+
+		for _, pass := range Passes {
+			if !o.passes[pass] {
+				continue
+			}
+
+			var err error
+
+			if node, err = o.run(pass, node); err != nil {
+				return nil, err
+			}
+
+			before := printed
+			printed = ast.NewPrinter(node).String()
+
+			if printed == before {
+				continue
+			}
+
+			changed = true
+
+			if o.dump != nil {
+				o.dump(pass, node)
+			}
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	return node, nil
+}
+
+func (o *Optimizer) run(pass string, node ast.Node) (ast.Node, error) {
+	var r *rewriter
+
+	switch pass {
+	case INLINE:
+		i := &inliner{}
+		i.self = i
+		r = &i.rewriter
+
+		i.pushFrame(node, true)
+	case BETA:
+		b := &reducer{}
+		b.self = b
+		r = &b.rewriter
+	case FOLD:
+		f := &folder{
+			pure: o.pureBuiltins(node),
+		}
+		f.self = f
+		r = &f.rewriter
+	case DEAD:
+		e := &eliminator{}
+		e.self = e
+		r = &e.rewriter
+	}
+
+	return r.rewrite(node)
+}
+
+// Returns the pure builtins which are not shadowed by the
+// environment or by any of the node's bindings.
+func (o *Optimizer) pureBuiltins(node ast.Node) map[string]object.Object {
+	bound := binders(node)
+	pure := map[string]object.Object{}
+
+	for name, obj := range o.builtins {
+		builtin, ok := obj.(*object.Builtin)
+
+		if ok && builtin.Pure && !bound[name] && !o.defined(name) {
+			pure[name] = builtin
+		}
+	}
+
+	return pure
+}
+
+func isPass(name string) bool {
+	for _, pass := range Passes {
+		if pass == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns a copy of the tree.
+func copyTree(node ast.Node) (ast.Node, error) {
+	r := &rewriter{}
+	r.self = r
+
+	return r.rewrite(node)
+}
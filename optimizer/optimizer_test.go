@@ -0,0 +1,143 @@
+package optimizer
+
+import (
+	"testing"
+
+	"raiton/ast"
+	"raiton/evaluator"
+	"raiton/lexer"
+	"raiton/object"
+	"raiton/parser"
+)
+
+func parse(t *testing.T, input string) ast.Node {
+	l := lexer.New(input)
+	p := parser.New(&l)
+	program, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("%s: %s", input, err)
+	}
+
+	return program
+}
+
+func TestOptimizer(t *testing.T) {
+	tests := []struct {
+		name     string
+		passes   []string
+		input    string
+		expected string
+	}{
+		{"fold", []string{FOLD}, "(add 1 (add 2 3))", "6"},
+		{"fold map", []string{FOLD}, "(map [1 2] \\n -> (add n 1))", "[2 3]"},
+		{"fold conditional", []string{FOLD}, "if true { (add 1 1) } else { 0 }", "2"},
+		{"fold keeps failures", []string{FOLD}, "(add 1 true)", "(add 1 true)"},
+		{"fold keeps shadowed", []string{FOLD}, "fn add a b -> a (add 1 2)", "fn add a b -> a (add 1 2)"},
+		{"fold keeps free names", []string{FOLD}, "x: 1 (add x 2)", "x: 1 (add x 2)"},
+		{"beta", []string{BETA}, "(\\x -> (f x x) 1)", "(f 1 1)"},
+		{"beta keeps effects", []string{BETA}, "(\\x -> (f x x) (g 1))", "(\\x -> (f x x) (g 1))"},
+		{"beta keeps unused", []string{BETA}, "(\\x -> 1 (g 1))", "(\\x -> 1 (g 1))"},
+		{"beta avoids capture", []string{BETA}, "(\\x -> \\y -> (f x y) y)", "(\\x -> \\y -> (f x y) y)"},
+		{"beta selects", []string{BETA}, "(\\r -> r.a r)", "r.a"},
+		{"beta computes keys", []string{BETA}, "(\\i -> xs.(i)..(add i 2) 1)", "xs.(1)..(add 1 2)"},
+		{"beta keeps computed effects", []string{BETA}, "(\\x -> (f x x) r.(g 1))", "(\\x -> (f x x) r.(g 1))"},
+		{"beta keeps defaults", []string{BETA}, "(\\x y: 1 -> (f x y) 2 3)", "(\\x y: 1 -> (f x y) 2 3)"},
+		{"beta keeps propagation", []string{BETA}, "fn k s { (\\x -> (f x)? s) }", "fn k s { (\\x -> (f x)? s) }"},
+		{"beta keeps propagation in loops", []string{BETA}, "fn k s { (\\x -> for y in x { y? } s) }", "fn k s { (\\x -> for y in x { y? } s) }"},
+		{"beta with nested propagation", []string{BETA}, "fn k s { (\\x -> \\y -> (f x y?) s) }", "fn k s { \\y -> (f s y?) }"},
+		{"inline", []string{INLINE}, "fn sq x -> (f x x) (sq 2)", "fn sq x -> (f x x) (\\x -> (f x x) 2)"},
+		{"inline keeps recursive", []string{INLINE}, "fn g x -> (g x) (g 2)", "fn g x -> (g x) (g 2)"},
+		{"inline keeps redefined", []string{INLINE}, "fn h x -> x h: 1 (h 2)", "fn h x -> x h: 1 (h 2)"},
+		{"inline keeps top level in functions", []string{INLINE}, "fn h x -> x fn k y -> (h y)", "fn h x -> x fn k y -> (h y)"},
+		{"inline in functions", []string{INLINE}, "fn k y { fn h x -> x (h y) }", "fn k y { fn h x -> x (\\x -> x y) }"},
+		{"inline keeps shadowed", []string{INLINE}, "fn k n { fn h x -> (f x n) \\n -> (h n) }", "fn k n { fn h x -> (f x n) \\n -> (h n) }"},
+		{"inline keeps rest parameters", []string{INLINE}, "fn h ...xs -> xs (h 2)", "fn h ...xs -> xs (h 2)"},
+		{"dead keeps defaults", []string{DEAD}, "fn k y { a: 1 \\x z: a -> z }", "fn k y { a: 1 \\x z: a -> z }"},
+		{"dead", []string{DEAD}, "fn k y { a: 1 b: \\x -> x c: (g y) y }", "fn k y { c: (g y) y }"},
+		{"dead keeps used", []string{DEAD}, "fn k y { a: 1 b: [a 2] b }", "fn k y { a: 1 b: [a 2] b }"},
+		{"dead keeps keys", []string{DEAD}, "fn k y { i: 1 y.(i) }", "fn k y { i: 1 y.(i) }"},
+		{"dead keeps top level", []string{DEAD}, "a: 1 5", "a: 1 5"},
+		{"all", Passes, "fn k y { fn sq x -> (add x x) unused: 4 (sq 3) }", "fn k y { 6 }"},
+	}
+
+	for _, tt := range tests {
+		o := New(WithPasses(tt.passes...))
+
+		optimized, err := o.Optimize(parse(t, tt.input))
+
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+
+		actual := ast.NewPrinter(optimized).String()
+		expected := ast.NewPrinter(parse(t, tt.expected)).String()
+
+		if actual != expected {
+			t.Errorf("%s: expected %q, but got %q", tt.name, expected, actual)
+		}
+	}
+}
+
+func TestOptimizerLeavesInputUntouched(t *testing.T) {
+	program := parse(t, "fn k y { fn sq x -> (add x x) unused: 4 (sq 3) } (add 1 2)")
+	printed := ast.NewPrinter(program).String()
+
+	o := New()
+
+	if _, err := o.Optimize(program); err != nil {
+		t.Fatal(err)
+	}
+
+	if ast.NewPrinter(program).String() != printed {
+		t.Fatalf("expected the program to be left untouched")
+	}
+}
+
+func TestOptimizerUnknownPass(t *testing.T) {
+	o := New(WithPasses("nope"))
+
+	if _, err := o.Optimize(parse(t, "1")); err == nil {
+		t.Fatal("expected error for unknown pass")
+	}
+}
+
+func TestOptimizedProgramsAgree(t *testing.T) {
+	tests := []string{
+		"fn sq x -> (add x x) fn k n { fn cube x -> (add (sq x) x) (cube n) } (add (k 3) (sq 4))",
+		"x: 10 fn f { y: x x: 5 (add x y) } (f)",
+		"fn twice f x -> (f (f x)) (twice \\n -> (add n 1) 5)",
+		"fn adder n -> \\x -> (add x n) add_two: (adder 2) (add_two 40)",
+		"fn f c { if c { x: 1 } else { 2 } x } x: 7 (f false)",
+		"fn k n { fn h x -> (add x n) g: \\n -> (h n) (g 1) } (k 10)",
+		"fn pick a { fn id x -> x if true { (id a) } else { 0 } } (pick 3)",
+		"fn parse s { n: ((\\x -> (str.to_number x)?) s) (Ok n) } [(parse \"1\") (parse \"a\")]",
+	}
+
+	for _, input := range tests {
+		e := evaluator.New(object.NewEnvironment())
+		expected, err := e.Evaluate(parse(t, input))
+
+		if err != nil {
+			t.Fatalf("%s: %s", input, err)
+		}
+
+		o := New()
+		optimized, err := o.Optimize(parse(t, input))
+
+		if err != nil {
+			t.Fatalf("%s: %s", input, err)
+		}
+
+		e = evaluator.New(object.NewEnvironment())
+		actual, err := e.Evaluate(optimized)
+
+		if err != nil {
+			t.Fatalf("%s: %s", ast.NewPrinter(optimized).String(), err)
+		}
+
+		if actual.Inspect() != expected.Inspect() {
+			t.Errorf("%s: expected %s, but got %s", input, expected.Inspect(), actual.Inspect())
+		}
+	}
+}
@@ -0,0 +1,553 @@
+package optimizer
+
+import (
+	"fmt"
+
+	"raiton/ast"
+)
+
+// rewriter copies the tree it visits, leaving the original untouched. Passes
+// embed it and override the methods of the nodes they transform. Children are
+// visited through self, so the overrides of a pass apply to nested nodes too.
+// Bindings and locals are not copied, they are resolved again before evaluation.
+type rewriter struct {
+	self ast.Visitor
+	// the node produced by the last visit
+	result ast.Node
+}
+
+func (r *rewriter) rewrite(node ast.Node) (ast.Node, error) {
+	if err := node.Accept(r.self); err != nil {
+		return nil, err
+	}
+
+	return r.result, nil
+}
+
+func (r *rewriter) rewriteItems(items []*ast.SelectorItem) ([]*ast.SelectorItem, error) {
+	rewritten := make([]*ast.SelectorItem, len(items))
+
+	for i, item := range items {
+		node, err := r.rewrite(item)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rewritten[i] = node.(*ast.SelectorItem)
+	}
+
+	return rewritten, nil
+}
+
+func (r *rewriter) rewriteAll(exprs []ast.Expression) ([]ast.Expression, error) {
+	rewritten := make([]ast.Expression, len(exprs))
+
+	for i, expr := range exprs {
+		node, err := r.rewrite(expr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rewritten[i] = node
+	}
+
+	return rewritten, nil
+}
+
+func (r *rewriter) rewriteScope(s *ast.Scope) (*ast.Scope, error) {
+	node, err := r.rewrite(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	scope, ok := node.(*ast.Scope)
+
+	if !ok {
+		return nil, fmt.Errorf("expected scope to be rewritten to a scope, but got %T", node)
+	}
+
+	return scope, nil
+}
+
+func (r *rewriter) rewriteDefinition(d *ast.Definition) (*ast.Definition, error) {
+	node, err := r.rewrite(d)
+
+	if err != nil {
+		return nil, err
+	}
+
+	def, ok := node.(*ast.Definition)
+
+	if !ok {
+		return nil, fmt.Errorf("expected definition to be rewritten to a definition, but got %T", node)
+	}
+
+	return def, nil
+}
+
+// Rewrites an item of a scope, which stays a definition if it is one.
+func (r *rewriter) rewriteScopeItem(item ast.Node) (ast.Node, error) {
+	if d, ok := item.(*ast.Definition); ok {
+		return r.rewriteDefinition(d)
+	}
+
+	return r.rewrite(item)
+}
+
+/*** Visitor Methods ***/
+
+func (r *rewriter) VisitScope(n *ast.Scope) error {
+	scope := &ast.Scope{
+		Items: make([]ast.Node, len(n.Items)),
+	}
+
+	for i, item := range n.Items {
+		rewritten, err := r.rewriteScopeItem(item)
+
+		if err != nil {
+			return err
+		}
+
+		scope.Items[i] = rewritten
+	}
+
+	r.result = scope
+
+	return nil
+}
+
+func (r *rewriter) VisitDefinition(n *ast.Definition) error {
+	expr, err := r.rewrite(n.Expression)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = &ast.Definition{
+		Identifier: n.Identifier,
+		Expression: expr,
+	}
+
+	return nil
+}
+
+func (r *rewriter) VisitIdentifier(n *ast.Identifier) error {
+	r.result = n
+	return nil
+}
+
+func (r *rewriter) VisitSelector(n *ast.Selector) error {
+	items, err := r.rewriteItems(n.Items)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = ast.NewSelector(items...)
+
+	return nil
+}
+
+func (r *rewriter) VisitSelectorItem(n *ast.SelectorItem) error {
+	if n.Expression == nil && n.End == nil {
+		r.result = n
+		return nil
+	}
+
+	item := &ast.SelectorItem{
+		Identifier: n.Identifier,
+		Index:      n.Index,
+	}
+
+	if n.Expression != nil {
+		expr, err := r.rewrite(n.Expression)
+
+		if err != nil {
+			return err
+		}
+
+		item.Expression = expr
+	}
+
+	if n.End != nil {
+		end, err := r.rewrite(n.End)
+
+		if err != nil {
+			return err
+		}
+
+		item.End = end.(*ast.SelectorItem)
+	}
+
+	r.result = item
+
+	return nil
+}
+
+func (r *rewriter) VisitApplication(n *ast.Application) error {
+	args, err := r.rewriteAll(n.Arguments)
+
+	if err != nil {
+		return err
+	}
+
+	app := ast.NewApplication(args...)
+
+	for _, a := range n.Named {
+		expr, err := r.rewrite(a.Expression)
+
+		if err != nil {
+			return err
+		}
+
+		app.Named = append(app.Named, &ast.NamedArgument{
+			Identifier: a.Identifier,
+			Expression: expr,
+		})
+	}
+
+	r.result = app
+
+	return nil
+}
+
+func (r *rewriter) VisitConditional(n *ast.Conditional) error {
+	condition, err := r.rewrite(n.Condition)
+
+	if err != nil {
+		return err
+	}
+
+	consequence, err := r.rewriteScope(n.Consequence)
+
+	if err != nil {
+		return err
+	}
+
+	alternative, err := r.rewrite(n.Alternative)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = &ast.Conditional{
+		Condition:   condition,
+		Consequence: consequence,
+		Alternative: alternative,
+	}
+
+	return nil
+}
+
+func (r *rewriter) VisitLazy(n *ast.Lazy) error {
+	expr, err := r.rewrite(n.Expression)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = &ast.Lazy{
+		Expression: expr,
+	}
+
+	return nil
+}
+
+func (r *rewriter) VisitFor(n *ast.For) error {
+	iterable, err := r.rewrite(n.Iterable)
+
+	if err != nil {
+		return err
+	}
+
+	body, err := r.rewrite(n.Body)
+
+	if err != nil {
+		return err
+	}
+
+	fn, ok := body.(*ast.FunctionLiteral)
+
+	if !ok {
+		return fmt.Errorf("expected loop body to be rewritten to a function, but got %T", body)
+	}
+
+	r.result = &ast.For{
+		Iterable: iterable,
+		Body:     fn,
+	}
+
+	return nil
+}
+
+func (r *rewriter) VisitBreak(n *ast.Break) error {
+	r.result = n
+	return nil
+}
+
+func (r *rewriter) VisitContinue(n *ast.Continue) error {
+	r.result = n
+	return nil
+}
+
+func (r *rewriter) VisitTry(n *ast.Try) error {
+	body, err := r.rewriteScope(n.Body)
+
+	if err != nil {
+		return err
+	}
+
+	try := &ast.Try{Body: body}
+
+	if n.Handler != nil {
+		node, err := r.rewrite(n.Handler)
+
+		if err != nil {
+			return err
+		}
+
+		handler, ok := node.(*ast.FunctionLiteral)
+
+		if !ok {
+			return fmt.Errorf("expected handler to be rewritten to a function, but got %T", node)
+		}
+
+		try.Handler = handler
+	}
+
+	if n.Finalizer != nil {
+		if try.Finalizer, err = r.rewriteScope(n.Finalizer); err != nil {
+			return err
+		}
+	}
+
+	r.result = try
+
+	return nil
+}
+
+func (r *rewriter) VisitPropagate(n *ast.Propagate) error {
+	expr, err := r.rewrite(n.Expression)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = &ast.Propagate{
+		Expression: expr,
+	}
+
+	return nil
+}
+
+func (r *rewriter) VisitAssignment(n *ast.Assignment) error {
+	target, err := r.rewrite(n.Target)
+
+	if err != nil {
+		return err
+	}
+
+	value, err := r.rewrite(n.Value)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = &ast.Assignment{
+		Target: target,
+		Value:  value,
+	}
+
+	return nil
+}
+
+func (r *rewriter) VisitComposition(n *ast.Composition) error {
+	fns, err := r.rewriteAll(n.Functions)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = &ast.Composition{
+		Functions: fns,
+	}
+
+	return nil
+}
+
+func (r *rewriter) VisitFunction(n *ast.FunctionLiteral) error {
+	var defaults []ast.Expression
+
+	for _, d := range n.Defaults {
+		var expr ast.Expression
+
+		if d != nil {
+			node, err := r.rewrite(d)
+
+			if err != nil {
+				return err
+			}
+
+			expr = node
+		}
+
+		defaults = append(defaults, expr)
+	}
+
+	body, err := r.rewriteScope(n.Body)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = &ast.FunctionLiteral{
+		Parameters: n.Parameters,
+		Defaults:   defaults,
+		Variadic:   n.Variadic,
+		Block:      n.Block,
+		Body:       body,
+	}
+
+	return nil
+}
+
+func (r *rewriter) VisitRecord(n *ast.RecordLiteral) error {
+	var base ast.Expression
+
+	if n.Base != nil {
+		var err error
+
+		if base, err = r.rewrite(n.Base); err != nil {
+			return err
+		}
+	}
+
+	fields := make([]*ast.RecordField, len(n.Fields))
+
+	for i, field := range n.Fields {
+		expr, err := r.rewrite(field.Expression)
+
+		if err != nil {
+			return err
+		}
+
+		fields[i] = &ast.RecordField{
+			Identifier: field.Identifier,
+			Expression: expr,
+			Spread:     field.Spread,
+		}
+	}
+
+	r.result = &ast.RecordLiteral{
+		Base:   base,
+		Fields: fields,
+	}
+
+	return nil
+}
+
+func (r *rewriter) VisitMap(n *ast.MapLiteral) error {
+	keys, err := r.rewriteAll(n.Keys)
+
+	if err != nil {
+		return err
+	}
+
+	values, err := r.rewriteAll(n.Values)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = &ast.MapLiteral{
+		Keys:   keys,
+		Values: values,
+	}
+
+	return nil
+}
+
+func (r *rewriter) VisitArray(n *ast.ArrayLiteral) error {
+	elements, err := r.rewriteAll(n.Elements)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = ast.NewArrayLiteral(n.Size, elements...)
+
+	return nil
+}
+
+func (r *rewriter) VisitSlice(n *ast.SliceLiteral) error {
+	elements, err := r.rewriteAll(n.Elements)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = ast.NewSliceLiteral(elements...)
+
+	return nil
+}
+
+func (r *rewriter) VisitTuple(n *ast.TupleLiteral) error {
+	elements, err := r.rewriteAll(n.Elements)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = ast.NewTupleLiteral(elements...)
+
+	return nil
+}
+
+func (r *rewriter) VisitUnpack(n *ast.Unpack) error {
+	tuple, err := r.rewrite(n.Tuple)
+
+	if err != nil {
+		return err
+	}
+
+	r.result = &ast.Unpack{
+		Tuple: tuple,
+		Index: n.Index,
+		Size:  n.Size,
+	}
+
+	return nil
+}
+
+func (r *rewriter) VisitInteger(n *ast.IntegerLiteral) error {
+	r.result = n
+	return nil
+}
+
+func (r *rewriter) VisitBigInteger(n *ast.BigIntegerLiteral) error {
+	r.result = n
+	return nil
+}
+
+func (r *rewriter) VisitFloat(n *ast.FloatLiteral) error {
+	r.result = n
+	return nil
+}
+
+func (r *rewriter) VisitString(n *ast.StringLiteral) error {
+	r.result = n
+	return nil
+}
+
+func (r *rewriter) VisitCharacter(n *ast.CharacterLiteral) error {
+	r.result = n
+	return nil
+}
+
+func (r *rewriter) VisitBoolean(n *ast.BooleanLiteral) error {
+	r.result = n
+	return nil
+}
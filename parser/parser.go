@@ -1,7 +1,9 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 
 	"raiton/ast"
@@ -13,6 +15,12 @@ type Parser struct {
 	lex       *lexer.Lexer
 	token     token.Token
 	peekToken *token.Token
+	// the number of loops around the current position in the function
+	// being parsed, since break and continue can't leave functions
+	loops int
+	// the number of functions around the current position, since a ?
+	// returns from the innermost one
+	functions int
 }
 
 func New(lex *lexer.Lexer) Parser {
@@ -31,10 +39,7 @@ func (p *Parser) Parse() (ast.Node, error) {
 /*** Productions ***/
 
 func (p *Parser) fileScope() (*ast.Scope, error) {
-	scope := &ast.Scope{
-		Definitions: make([]*ast.Definition, 0),
-		Expressions: make([]ast.Expression, 0),
-	}
+	scope := ast.NewScope()
 
 	for !p.match(token.EOF) {
 		if err := p.scopeItem(scope); err != nil {
@@ -46,10 +51,7 @@ func (p *Parser) fileScope() (*ast.Scope, error) {
 }
 
 func (p *Parser) scope() (*ast.Scope, error) {
-	scope := &ast.Scope{
-		Definitions: make([]*ast.Definition, 0),
-		Expressions: make([]ast.Expression, 0),
-	}
+	scope := ast.NewScope()
 
 	p.consume(token.OPEN_BRACE)
 
@@ -80,7 +82,19 @@ func (p *Parser) scopeItem(scope *ast.Scope) error {
 				return err
 			}
 
-			scope.Expressions = append(scope.Expressions, selector)
+			propagated, err := p.propagation(selector)
+
+			if err != nil {
+				return err
+			}
+
+			expression, err := p.assignment(propagated)
+
+			if err != nil {
+				return err
+			}
+
+			scope.Items = append(scope.Items, expression)
 		case p.match(token.COLON) || p.match(token.OPEN_BRACE):
 			definition, err := p.definition(ident)
 
@@ -88,9 +102,21 @@ func (p *Parser) scopeItem(scope *ast.Scope) error {
 				return err
 			}
 
-			scope.Definitions = append(scope.Definitions, definition)
+			scope.Items = append(scope.Items, definition)
 		default:
-			scope.Expressions = append(scope.Expressions, ident)
+			propagated, err := p.propagation(ast.NewSelector(ast.NewIdentifierSelector(ident)))
+
+			if err != nil {
+				return err
+			}
+
+			expression, err := p.assignment(propagated)
+
+			if err != nil {
+				return err
+			}
+
+			scope.Items = append(scope.Items, expression)
 		}
 	} else if p.match(token.FUNCTION) {
 		funcDef, err := p.functionDefinition()
@@ -99,13 +125,28 @@ func (p *Parser) scopeItem(scope *ast.Scope) error {
 			return err
 		}
 
-		scope.Definitions = append(scope.Definitions, funcDef)
+		scope.Items = append(scope.Items, funcDef)
 	} else {
 		expression, err := p.expression()
 		if err != nil {
 			return err
 		}
-		scope.Expressions = append(scope.Expressions, expression)
+
+		if pattern, ok := expression.(*ast.TupleLiteral); ok && p.match(token.COLON) {
+			definitions, err := p.destructuringDefinition(pattern)
+
+			if err != nil {
+				return err
+			}
+
+			for _, definition := range definitions {
+				scope.Items = append(scope.Items, definition)
+			}
+
+			return nil
+		}
+
+		scope.Items = append(scope.Items, expression)
 	}
 
 	return nil
@@ -142,6 +183,191 @@ func (p *Parser) definition(ident *ast.Identifier) (*ast.Definition, error) {
 	}
 }
 
+// Parses the value of a destructuring definition, like `(q, r): (divmod 7 2)`,
+// and returns the definitions of the tuple and of the names in the pattern.
+func (p *Parser) destructuringDefinition(pattern *ast.TupleLiteral) ([]*ast.Definition, error) {
+	p.consume(token.COLON)
+
+	expr, err := p.expression()
+
+	if err != nil {
+		return nil, err
+	}
+
+	name := patternName(pattern)
+
+	elements, err := p.destructure(pattern, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	definition := &ast.Definition{
+		Identifier: name,
+		Expression: expr,
+	}
+
+	return append([]*ast.Definition{definition}, elements...), nil
+}
+
+// Returns the definitions of the names in the pattern, as elements of the
+// tuple bound to the given name. Elements of the pattern are either names,
+// or patterns themselves, for tuples nested in the tuple.
+func (p *Parser) destructure(pattern *ast.TupleLiteral, name ast.Identifier) ([]*ast.Definition, error) {
+	definitions := []*ast.Definition{}
+
+	for i, element := range pattern.Elements {
+		unpack := &ast.Unpack{
+			Tuple: ast.NewSelector(ast.NewIdentifierSelector(&name)),
+			Index: i,
+			Size:  len(pattern.Elements),
+		}
+
+		switch element := element.(type) {
+		case *ast.Selector:
+			if len(element.Items) != 1 {
+				return nil, fmt.Errorf("expected a name in the pattern %s, but got a selector on line %d column %d", name, p.token.Line, p.token.Column)
+			}
+
+			definitions = append(definitions, &ast.Definition{
+				Identifier: *element.Items[0].Identifier,
+				Expression: unpack,
+			})
+		case *ast.TupleLiteral:
+			nested := patternName(element)
+
+			definitions = append(definitions, &ast.Definition{
+				Identifier: nested,
+				Expression: unpack,
+			})
+
+			elements, err := p.destructure(element, nested)
+
+			if err != nil {
+				return nil, err
+			}
+
+			definitions = append(definitions, elements...)
+		default:
+			return nil, fmt.Errorf("expected a name or a tuple of names in the pattern %s on line %d column %d", name, p.token.Line, p.token.Column)
+		}
+	}
+
+	return definitions, nil
+}
+
+// Returns the name the tuple a pattern destructures is bound to, which is
+// the pattern as it is written, so it can't clash with names in programs.
+func patternName(pattern *ast.TupleLiteral) ast.Identifier {
+	return ast.Identifier(ast.NewPrinter(pattern).String())
+}
+
+// Parses the parameters of a function into the function literal. Parameters
+// are names, or tuple patterns like `(x, y)`, and may be followed by a colon
+// and a default value. The last one may be a rest parameter, like `...rest`.
+// The definitions of the names in patterns are returned, to be put at the
+// start of the function's body.
+func (p *Parser) parameters(fn *ast.FunctionLiteral) ([]*ast.Definition, error) {
+	fn.Parameters = []*ast.Identifier{}
+	definitions := []*ast.Definition{}
+	defaults := []ast.Expression{}
+	defaulted := false
+
+	for p.match(token.IDENTIFIER) || p.match(token.OPEN_PAREN) || p.match(token.SPREAD) {
+		if fn.Variadic {
+			return nil, fmt.Errorf("expected the rest parameter to be the last one on line %d column %d", p.token.Line, p.token.Column)
+		}
+
+		line, column := p.token.Line, p.token.Column
+
+		if p.match(token.SPREAD) {
+			p.consume(token.SPREAD)
+
+			if err := p.expect(token.IDENTIFIER); err != nil {
+				return nil, err
+			}
+
+			fn.Parameters = append(fn.Parameters, p.identifier())
+			fn.Variadic = true
+
+			if p.match(token.COLON) {
+				return nil, fmt.Errorf("rest parameter cannot have a default value on line %d column %d", p.token.Line, p.token.Column)
+			}
+
+			defaults = append(defaults, nil)
+
+			continue
+		}
+
+		if p.match(token.IDENTIFIER) {
+			fn.Parameters = append(fn.Parameters, p.identifier())
+		} else {
+			expr, err := p.invocation()
+
+			if err != nil {
+				return nil, err
+			}
+
+			pattern, ok := expr.(*ast.TupleLiteral)
+
+			if !ok {
+				return nil, fmt.Errorf("expected a parameter to be a name or a tuple pattern on line %d column %d", p.token.Line, p.token.Column)
+			}
+
+			name := patternName(pattern)
+			fn.Parameters = append(fn.Parameters, &name)
+
+			elements, err := p.destructure(pattern, name)
+
+			if err != nil {
+				return nil, err
+			}
+
+			definitions = append(definitions, elements...)
+		}
+
+		param := fn.Parameters[len(fn.Parameters)-1]
+
+		if !p.match(token.COLON) {
+			if defaulted {
+				return nil, fmt.Errorf("expected parameter '%s' to have a default value, like the ones before it, on line %d column %d", *param, line, column)
+			}
+
+			defaults = append(defaults, nil)
+
+			continue
+		}
+
+		p.consume(token.COLON)
+
+		value, err := p.expression()
+
+		if err != nil {
+			return nil, err
+		}
+
+		defaults = append(defaults, value)
+		defaulted = true
+	}
+
+	if defaulted {
+		fn.Defaults = defaults
+	}
+
+	return definitions, nil
+}
+
+// Puts the definitions at the start of the scope, before its items.
+func prepend(scope *ast.Scope, definitions []*ast.Definition) {
+	items := make([]ast.Node, 0, len(definitions)+len(scope.Items))
+
+	for _, definition := range definitions {
+		items = append(items, definition)
+	}
+
+	scope.Items = append(items, scope.Items...)
+}
+
 func (p *Parser) functionDefinition() (*ast.Definition, error) {
 	if err := p.expect(token.FUNCTION); err != nil {
 		return nil, err
@@ -157,12 +383,13 @@ func (p *Parser) functionDefinition() (*ast.Definition, error) {
 
 	p.consume(token.IDENTIFIER)
 
-	parameters := []*ast.Identifier{}
+	defer p.enterFunction()()
 
-	for p.match(token.IDENTIFIER) {
-		param := ast.NewIdentifier(p.token.Literal)
-		parameters = append(parameters, param)
-		p.consume(token.IDENTIFIER)
+	fn := &ast.FunctionLiteral{}
+	destructured, err := p.parameters(fn)
+
+	if err != nil {
+		return nil, err
 	}
 
 	if p.match(token.ARROW) {
@@ -174,38 +401,157 @@ func (p *Parser) functionDefinition() (*ast.Definition, error) {
 			return nil, err
 		}
 
-		expr = &ast.FunctionLiteral{
-			Parameters: parameters,
-			Body:       ast.ScopeExpressions(expr),
-		}
+		fn.Body = ast.ScopeExpressions(expr)
+		prepend(fn.Body, destructured)
 
 		return &ast.Definition{
 			Identifier: ident,
-			Expression: expr,
+			Expression: fn,
 		}, nil
 	} else if p.match(token.OPEN_BRACE) {
 		scope, err := p.scope()
-		expr := ast.Expression(scope)
 
 		if err != nil {
 			return nil, err
 		}
 
-		expr = &ast.FunctionLiteral{
-			Parameters: parameters,
-			Body:       scope,
-		}
+		prepend(scope, destructured)
+		fn.Body = scope
 
 		return &ast.Definition{
 			Identifier: ident,
-			Expression: expr,
+			Expression: fn,
 		}, nil
 	} else {
 		return nil, p.unexpected()
 	}
 }
 
+// Parses an expression, which may be a pipeline, like `xs |> (map f)`,
+// where the value on the left becomes the first argument of the
+// application on the right, or of the function on the right if it is
+// not an application. Compositions, like `f >> g`, bind tighter than
+// pipelines, and are applications of the `compose` builtin. Assignments,
+// like `counter := 1`, bind looser than both, and are applications of
+// the `set!` builtin to the reference on the left and the value on the
+// right.
 func (p *Parser) expression() (ast.Expression, error) {
+	operand, err := p.operand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return p.assignment(operand)
+}
+
+// Parses the rest of an expression after its first operand, which may
+// be the target of an assignment.
+func (p *Parser) assignment(operand ast.Expression) (ast.Expression, error) {
+	target, err := p.pipeline(operand)
+
+	if err != nil || !p.match(token.ASSIGN) {
+		return target, err
+	}
+
+	p.consume(token.ASSIGN)
+
+	value, err := p.expression()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Assignment{Target: target, Value: value}, nil
+}
+
+// Parses the rest of a pipeline after its first operand.
+func (p *Parser) pipeline(operand ast.Expression) (ast.Expression, error) {
+	value, err := p.composition(operand)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.PIPE) {
+		p.consume(token.PIPE)
+
+		next, err := p.operand()
+
+		if err != nil {
+			return nil, err
+		}
+
+		stage, err := p.composition(next)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// compositions are functions, rather than applications to add to
+		app, ok := next.(*ast.Application)
+
+		if !ok || stage != next || len(app.Arguments) == 0 {
+			value = ast.NewApplication(stage, value)
+			continue
+		}
+
+		args := append([]ast.Expression{app.Arguments[0], value}, app.Arguments[1:]...)
+		value = &ast.Application{Arguments: args, Named: app.Named}
+	}
+
+	return value, nil
+}
+
+func (p *Parser) composition(fn ast.Expression) (ast.Expression, error) {
+	if !p.match(token.COMPOSE) {
+		return fn, nil
+	}
+
+	composition := &ast.Composition{Functions: []ast.Expression{fn}}
+
+	for p.match(token.COMPOSE) {
+		p.consume(token.COMPOSE)
+
+		next, err := p.operand()
+
+		if err != nil {
+			return nil, err
+		}
+
+		composition.Functions = append(composition.Functions, next)
+	}
+
+	return composition, nil
+}
+
+// Parses an operand, which may be followed by a ?, like `(parse s)?`.
+func (p *Parser) operand() (ast.Expression, error) {
+	operand, err := p.primary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return p.propagation(operand)
+}
+
+// Parses the ?s after an operand, which have to be in a function, since
+// they return from it.
+func (p *Parser) propagation(operand ast.Expression) (ast.Expression, error) {
+	for p.match(token.QUESTION) {
+		if p.functions == 0 {
+			return nil, fmt.Errorf("? outside of a function on line %d column %d", p.token.Line, p.token.Column)
+		}
+
+		p.consume(token.QUESTION)
+		operand = &ast.Propagate{Expression: operand}
+	}
+
+	return operand, nil
+}
+
+func (p *Parser) primary() (ast.Expression, error) {
 	if p.match(token.IDENTIFIER) {
 		return p.selector(nil)
 	} else if p.match(token.NUMBER) || p.match(token.MINUS) {
@@ -220,10 +566,22 @@ func (p *Parser) expression() (ast.Expression, error) {
 		return p.arrayOrSlice()
 	} else if p.match(token.OPEN_BRACE) {
 		return p.record()
+	} else if p.match(token.OPEN_MAP) {
+		return p.mapLiteral()
 	} else if p.match(token.BACKSLASH) {
 		return p.function()
 	} else if p.match(token.OPEN_PAREN) {
 		return p.invocation()
+	} else if p.match(token.IF) {
+		return p.conditional()
+	} else if p.match(token.LAZY) {
+		return p.lazy()
+	} else if p.match(token.FOR) {
+		return p.loop()
+	} else if p.match(token.BREAK) || p.match(token.CONTINUE) {
+		return p.jump()
+	} else if p.match(token.TRY) {
+		return p.try()
 	} else {
 		return nil, p.unexpected()
 	}
@@ -257,18 +615,28 @@ func (p *Parser) selector(ident *ast.Identifier) (ast.Expression, error) {
 			ident := p.identifier()
 			item := ast.NewIdentifierSelector(ident)
 			items = append(items, item)
-		} else if p.match(token.NUMBER) {
-			num, err := p.unsignedInteger()
+			continue
+		}
+
+		item, err := p.selectorIndex()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if p.match(token.DOT_DOT) {
+			p.consume(token.DOT_DOT)
+
+			end, err := p.selectorIndex()
 
 			if err != nil {
 				return nil, err
 			}
 
-			item := ast.NewIndexSelector(num.(*ast.IntegerLiteral))
-			items = append(items, item)
-		} else {
-			return nil, p.unexpected()
+			item = ast.NewRangeSelector(item, end)
 		}
+
+		items = append(items, item)
 	}
 
 	return &ast.Selector{
@@ -276,6 +644,49 @@ func (p *Parser) selector(ident *ast.Identifier) (ast.Expression, error) {
 	}, nil
 }
 
+// Parses an index of a selector, which is an integer, or an expression in
+// parentheses. The parentheses may also hold an application without its own
+// parentheses, so `arr.(add i 1)` is the same as `arr.((add i 1))`.
+func (p *Parser) selectorIndex() (*ast.SelectorItem, error) {
+	if p.match(token.OPEN_PAREN) {
+		expr, err := p.invocation()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if app, ok := expr.(*ast.Application); ok && len(app.Arguments) == 1 {
+			expr = app.Arguments[0]
+		}
+
+		return ast.NewComputedSelector(expr), nil
+	}
+
+	negative := p.match(token.MINUS)
+
+	if negative {
+		p.consume(token.MINUS)
+	}
+
+	if !p.match(token.NUMBER) {
+		return nil, p.unexpected()
+	}
+
+	num, err := p.unsignedInteger()
+
+	if err != nil {
+		return nil, err
+	}
+
+	index := num.(*ast.IntegerLiteral)
+
+	if negative {
+		index = ast.NewIntegerLiteral(-int64(*index))
+	}
+
+	return ast.NewIndexSelector(index), nil
+}
+
 func (p *Parser) number() (ast.Expression, error) {
 	numberStr := ""
 
@@ -313,6 +724,13 @@ func (p *Parser) number() (ast.Expression, error) {
 
 	value, err := strconv.ParseInt(numberStr, 0, 64)
 
+	if errors.Is(err, strconv.ErrRange) {
+		// too large for an int64
+		if value, ok := new(big.Int).SetString(numberStr, 0); ok {
+			return ast.NewBigIntegerLiteral(value), nil
+		}
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -344,6 +762,10 @@ func (p *Parser) boolean() (ast.Expression, error) {
 
 func (p *Parser) string() (ast.Expression, error) {
 	p.consume(token.DOUBLE_QUOTE)
+	if p.match(token.DOUBLE_QUOTE) {
+		p.consume(token.DOUBLE_QUOTE)
+		return ast.NewStringLiteral(""), nil
+	}
 	if err := p.expect(token.STRING); err != nil {
 		return nil, err
 	}
@@ -461,11 +883,52 @@ func (p *Parser) record() (ast.Expression, error) {
 	p.consume(token.OPEN_BRACE)
 
 	recordLiteral := ast.RecordLiteral{
-		Fields: map[ast.Identifier]ast.Expression{},
+		Fields: []*ast.RecordField{},
 	}
 
-	for p.match(token.IDENTIFIER) {
+	if !p.match(token.CLOSED_BRACE) && !p.match(token.SPREAD) && !p.field() {
+		base, err := p.expression()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(token.BAR); err != nil {
+			return nil, err
+		}
+
+		p.consume(token.BAR)
+
+		recordLiteral.Base = base
+	}
+
+	defined := map[ast.Identifier]bool{}
+
+	for p.match(token.IDENTIFIER) || p.match(token.SPREAD) && recordLiteral.Base == nil {
+		if p.match(token.SPREAD) {
+			p.consume(token.SPREAD)
+
+			expression, err := p.expression()
+
+			if err != nil {
+				return nil, err
+			}
+
+			recordLiteral.Fields = append(recordLiteral.Fields, &ast.RecordField{
+				Expression: expression,
+				Spread:     true,
+			})
+
+			continue
+		}
+
 		field := ast.Identifier(p.token.Literal)
+
+		if defined[field] {
+			return nil, fmt.Errorf("field '%s' is defined more than once in record on line %d column %d", field, p.token.Line, p.token.Column)
+		}
+
+		defined[field] = true
 		p.consume(token.IDENTIFIER)
 
 		if err := p.expect(token.COLON); err != nil {
@@ -480,7 +943,10 @@ func (p *Parser) record() (ast.Expression, error) {
 			return nil, err
 		}
 
-		recordLiteral.Fields[field] = expression
+		recordLiteral.Fields = append(recordLiteral.Fields, &ast.RecordField{
+			Identifier: field,
+			Expression: expression,
+		})
 	}
 
 	if err := p.expect(token.CLOSED_BRACE); err != nil {
@@ -492,17 +958,64 @@ func (p *Parser) record() (ast.Expression, error) {
 	return &recordLiteral, nil
 }
 
+// Reports whether the next tokens start a field of a record, as opposed
+// to the base of a record update.
+func (p *Parser) field() bool {
+	if !p.match(token.IDENTIFIER) {
+		return false
+	}
+
+	p.peek()
+
+	return p.peekMatch(token.COLON)
+}
+
+func (p *Parser) mapLiteral() (ast.Expression, error) {
+	p.consume(token.OPEN_MAP)
+
+	mapLiteral := ast.MapLiteral{
+		Keys:   []ast.Expression{},
+		Values: []ast.Expression{},
+	}
+
+	for !p.match(token.CLOSED_BRACE) {
+		key, err := p.expression()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(token.COLON); err != nil {
+			return nil, err
+		}
+
+		p.consume(token.COLON)
+
+		value, err := p.expression()
+
+		if err != nil {
+			return nil, err
+		}
+
+		mapLiteral.Keys = append(mapLiteral.Keys, key)
+		mapLiteral.Values = append(mapLiteral.Values, value)
+	}
+
+	p.consume(token.CLOSED_BRACE)
+
+	return &mapLiteral, nil
+}
+
 func (p *Parser) function() (ast.Expression, error) {
 	p.consume(token.BACKSLASH)
 
-	functionLiteral := ast.FunctionLiteral{
-		Parameters: []*ast.Identifier{},
-	}
+	defer p.enterFunction()()
 
-	for p.match(token.IDENTIFIER) {
-		param := ast.Identifier(p.token.Literal)
-		functionLiteral.Parameters = append(functionLiteral.Parameters, &param)
-		p.consume(token.IDENTIFIER)
+	functionLiteral := ast.FunctionLiteral{}
+	destructured, err := p.parameters(&functionLiteral)
+
+	if err != nil {
+		return nil, err
 	}
 
 	if p.match(token.ARROW) {
@@ -526,6 +1039,8 @@ func (p *Parser) function() (ast.Expression, error) {
 		return &ast.Definition{}, p.unexpected()
 	}
 
+	prepend(functionLiteral.Body, destructured)
+
 	return &functionLiteral, nil
 }
 
@@ -537,10 +1052,29 @@ func (p *Parser) invocation() (ast.Expression, error) {
 	}
 
 	for !p.match(token.EOF) && !p.match(token.CLOSED_PAREN) {
+		// the function is never a named argument, and named arguments come last
+		if len(invocation.Arguments) > 0 && (p.field() || len(invocation.Named) > 0) {
+			named, err := p.namedArgument(invocation.Named)
+
+			if err != nil {
+				return nil, err
+			}
+
+			invocation.Named = append(invocation.Named, named)
+
+			continue
+		}
+
 		expression, err := p.expression()
 		if err != nil {
 			return nil, err
 		}
+
+		// a comma after the first expression makes it the first element of a tuple
+		if p.match(token.COMMA) && len(invocation.Arguments) == 0 {
+			return p.tuple(expression)
+		}
+
 		invocation.Arguments = append(invocation.Arguments, expression)
 	}
 
@@ -553,6 +1087,265 @@ func (p *Parser) invocation() (ast.Expression, error) {
 	return &invocation, nil
 }
 
+// Parses an argument passed by name, like `port: 80`.
+func (p *Parser) namedArgument(previous []*ast.NamedArgument) (*ast.NamedArgument, error) {
+	if !p.field() {
+		return nil, fmt.Errorf("expected a named argument after named arguments on line %d column %d", p.token.Line, p.token.Column)
+	}
+
+	for _, arg := range previous {
+		if string(arg.Identifier) == p.token.Literal {
+			return nil, fmt.Errorf("argument '%s' is named more than once in application on line %d column %d", arg.Identifier, p.token.Line, p.token.Column)
+		}
+	}
+
+	ident := p.identifier()
+	p.consume(token.COLON)
+
+	expr, err := p.expression()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.NamedArgument{
+		Identifier: *ident,
+		Expression: expr,
+	}, nil
+}
+
+// Parses the rest of a tuple after its first element. Elements are separated
+// by commas, and a comma may follow the last one, which is how tuples of one
+// element are written, like `(x,)`.
+func (p *Parser) tuple(first ast.Expression) (ast.Expression, error) {
+	tuple := ast.NewTupleLiteral(first)
+
+	for p.match(token.COMMA) {
+		p.consume(token.COMMA)
+
+		if p.match(token.CLOSED_PAREN) {
+			break
+		}
+
+		element, err := p.expression()
+
+		if err != nil {
+			return nil, err
+		}
+
+		tuple.Elements = append(tuple.Elements, element)
+	}
+
+	if err := p.expect(token.CLOSED_PAREN); err != nil {
+		return nil, err
+	}
+
+	p.consume(token.CLOSED_PAREN)
+
+	return tuple, nil
+}
+
+func (p *Parser) conditional() (ast.Expression, error) {
+	p.consume(token.IF)
+
+	condition, err := p.expression()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(token.OPEN_BRACE); err != nil {
+		return nil, err
+	}
+
+	consequence, err := p.scope()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(token.ELSE); err != nil {
+		return nil, err
+	}
+
+	p.consume(token.ELSE)
+
+	var alternative ast.Expression
+
+	if p.match(token.IF) {
+		alternative, err = p.conditional()
+	} else if p.match(token.OPEN_BRACE) {
+		alternative, err = p.scope()
+	} else {
+		return nil, p.unexpected()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Conditional{
+		Condition:   condition,
+		Consequence: consequence,
+		Alternative: alternative,
+	}, nil
+}
+
+func (p *Parser) lazy() (ast.Expression, error) {
+	p.consume(token.LAZY)
+
+	expression, err := p.expression()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Lazy{
+		Expression: expression,
+	}, nil
+}
+
+// Parses a loop, like `for x in xs { (println x) }`. The element is bound
+// to a name or to a tuple pattern, like a parameter of the function the
+// body becomes.
+func (p *Parser) loop() (ast.Expression, error) {
+	p.consume(token.FOR)
+
+	line, column := p.token.Line, p.token.Column
+
+	body := &ast.FunctionLiteral{Block: true}
+	destructured, err := p.parameters(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body.Parameters) != 1 || body.Variadic || body.Defaults != nil {
+		return nil, fmt.Errorf("expected a name or a tuple pattern to bind the elements to on line %d column %d", line, column)
+	}
+
+	if err := p.expect(token.IN); err != nil {
+		return nil, err
+	}
+
+	p.consume(token.IN)
+
+	iterable, err := p.expression()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(token.OPEN_BRACE); err != nil {
+		return nil, err
+	}
+
+	p.loops++
+	scope, err := p.scope()
+	p.loops--
+
+	if err != nil {
+		return nil, err
+	}
+
+	prepend(scope, destructured)
+	body.Body = scope
+
+	return &ast.For{
+		Iterable: iterable,
+		Body:     body,
+	}, nil
+}
+
+// Parses a break or a continue, which have to be in the body of a loop.
+func (p *Parser) jump() (ast.Expression, error) {
+	if p.loops == 0 {
+		return nil, fmt.Errorf("%s outside of a loop on line %d column %d", p.token.Literal, p.token.Line, p.token.Column)
+	}
+
+	if p.match(token.BREAK) {
+		p.consume(token.BREAK)
+		return &ast.Break{}, nil
+	}
+
+	p.consume(token.CONTINUE)
+
+	return &ast.Continue{}, nil
+}
+
+// Parses a try, like `try { (risky) } catch e { e.message } finally { (done) }`,
+// which needs a catch, a finally, or both. The handler binds the error to a
+// name, like a parameter of the function it becomes.
+func (p *Parser) try() (ast.Expression, error) {
+	line, column := p.token.Line, p.token.Column
+
+	p.consume(token.TRY)
+
+	if err := p.expect(token.OPEN_BRACE); err != nil {
+		return nil, err
+	}
+
+	body, err := p.scope()
+
+	if err != nil {
+		return nil, err
+	}
+
+	try := &ast.Try{Body: body}
+
+	if p.match(token.CATCH) {
+		p.consume(token.CATCH)
+
+		if err := p.expect(token.IDENTIFIER); err != nil {
+			return nil, err
+		}
+
+		try.Handler = &ast.FunctionLiteral{
+			Parameters: []*ast.Identifier{p.identifier()},
+			Block:      true,
+		}
+
+		if err := p.expect(token.OPEN_BRACE); err != nil {
+			return nil, err
+		}
+
+		if try.Handler.Body, err = p.scope(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.match(token.FINALLY) {
+		p.consume(token.FINALLY)
+
+		if err := p.expect(token.OPEN_BRACE); err != nil {
+			return nil, err
+		}
+
+		if try.Finalizer, err = p.scope(); err != nil {
+			return nil, err
+		}
+	}
+
+	if try.Handler == nil && try.Finalizer == nil {
+		return nil, fmt.Errorf("expected a catch or a finally after the try on line %d column %d", line, column)
+	}
+
+	return try, nil
+}
+
+// Starts parsing a function, which is outside of the loops around it,
+// and returns a function to call once it is parsed.
+func (p *Parser) enterFunction() func() {
+	loops := p.loops
+	p.loops = 0
+	p.functions++
+
+	return func() {
+		p.loops = loops
+		p.functions--
+	}
+}
+
 /*** Parser utility methods ***/
 
 func parseArraySize(literal string) (uint64, error) {
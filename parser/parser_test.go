@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"math"
+	"math/big"
 	"testing"
 
 	"raiton/ast"
@@ -27,7 +29,7 @@ func TestExpressionString(t *testing.T) {
 	source := `"this is a string"`
 
 	expected := ast.Scope{
-		Expressions: []ast.Expression{
+		Items: []ast.Node{
 			ast.NewStringLiteral("this is a string"),
 		},
 	}
@@ -35,11 +37,23 @@ func TestExpressionString(t *testing.T) {
 	parseAndCompare(t, source, &expected)
 }
 
+func TestExpressionEmptyString(t *testing.T) {
+	source := `""`
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			ast.NewStringLiteral(""),
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
 func TestExpressionCharacter(t *testing.T) {
 	source := `'c'`
 
 	expected := ast.Scope{
-		Expressions: []ast.Expression{
+		Items: []ast.Node{
 			ast.NewCharacterLiteral("c"),
 		},
 	}
@@ -60,14 +74,502 @@ func TestExpressionNumber(t *testing.T) {
 
 	# number expression; negative float
 	-3.14
+
+	# number expression; integer too large for an int64
+	-9223372036854775809
 	`
 
 	expected := ast.Scope{
-		Expressions: []ast.Expression{
+		Items: []ast.Node{
 			ast.NewIntegerLiteral(5),
 			ast.NewFloatLiteral(2.65),
 			ast.NewIntegerLiteral(-1),
 			ast.NewFloatLiteral(-3.14),
+			ast.NewBigIntegerLiteral(big.NewInt(0).Sub(big.NewInt(math.MinInt64), big.NewInt(1))),
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestExpressionRecord(t *testing.T) {
+	source := `{ z: 1 a: "a" m: [1] }`
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.RecordLiteral{
+				Fields: []*ast.RecordField{
+					{Identifier: "z", Expression: ast.NewIntegerLiteral(1)},
+					{Identifier: "a", Expression: ast.NewStringLiteral("a")},
+					{Identifier: "m", Expression: ast.NewSliceLiteral(ast.NewIntegerLiteral(1))},
+				},
+			},
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestExpressionSelectorIndexes(t *testing.T) {
+	source := `arr.-1 arr.(i) arr.(add i 1) arr.1..(len arr)`
+
+	arr := ast.NewIdentifierSelector(ast.NewIdentifier("arr"))
+	i := &ast.Selector{
+		Items: []*ast.SelectorItem{ast.NewIdentifierSelector(ast.NewIdentifier("i"))},
+	}
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			ast.NewSelector(arr, ast.NewIndexSelector(ast.NewIntegerLiteral(-1))),
+			ast.NewSelector(arr, ast.NewComputedSelector(i)),
+			ast.NewSelector(arr, ast.NewComputedSelector(ast.NewApplication(
+				ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("add"))),
+				i,
+				ast.NewIntegerLiteral(1),
+			))),
+			ast.NewSelector(arr, ast.NewRangeSelector(
+				ast.NewIndexSelector(ast.NewIntegerLiteral(1)),
+				ast.NewComputedSelector(ast.NewApplication(
+					ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("len"))),
+					ast.NewSelector(arr),
+				)),
+			)),
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestExpressionTuple(t *testing.T) {
+	source := `(1, "a") (x,) ((f x), (g),)`
+
+	x := ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("x")))
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			ast.NewTupleLiteral(ast.NewIntegerLiteral(1), ast.NewStringLiteral("a")),
+			ast.NewTupleLiteral(x),
+			ast.NewTupleLiteral(
+				ast.NewApplication(ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("f"))), x),
+				ast.NewApplication(ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("g")))),
+			),
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestDestructuringDefinition(t *testing.T) {
+	source := `(q, (r, s)): t`
+
+	tuple := func(name string) *ast.Selector {
+		return ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier(name)))
+	}
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.Definition{Identifier: "(q, (r, s))", Expression: tuple("t")},
+			&ast.Definition{Identifier: "q", Expression: &ast.Unpack{Tuple: tuple("(q, (r, s))"), Index: 0, Size: 2}},
+			&ast.Definition{Identifier: "(r, s)", Expression: &ast.Unpack{Tuple: tuple("(q, (r, s))"), Index: 1, Size: 2}},
+			&ast.Definition{Identifier: "r", Expression: &ast.Unpack{Tuple: tuple("(r, s)"), Index: 0, Size: 2}},
+			&ast.Definition{Identifier: "s", Expression: &ast.Unpack{Tuple: tuple("(r, s)"), Index: 1, Size: 2}},
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestFunctionTupleParameter(t *testing.T) {
+	source := `\(x, y) z -> z`
+
+	pattern := ast.Identifier("(x, y)")
+	tuple := ast.NewSelector(ast.NewIdentifierSelector(&pattern))
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.FunctionLiteral{
+				Parameters: []*ast.Identifier{&pattern, ast.NewIdentifier("z")},
+				Body: &ast.Scope{
+					Items: []ast.Node{
+						&ast.Definition{Identifier: "x", Expression: &ast.Unpack{Tuple: tuple, Index: 0, Size: 2}},
+						&ast.Definition{Identifier: "y", Expression: &ast.Unpack{Tuple: tuple, Index: 1, Size: 2}},
+						ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("z"))),
+					},
+				},
+			},
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestFunctionParameterDefaultsAndRest(t *testing.T) {
+	source := `\host port: 80 ...rest -> host`
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.FunctionLiteral{
+				Parameters: []*ast.Identifier{ast.NewIdentifier("host"), ast.NewIdentifier("port"), ast.NewIdentifier("rest")},
+				Defaults:   []ast.Expression{nil, ast.NewIntegerLiteral(80), nil},
+				Variadic:   true,
+				Body: ast.ScopeExpressions(
+					ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("host"))),
+				),
+			},
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestExpressionNamedArguments(t *testing.T) {
+	source := `(connect "x" port: 80 secure: (not insecure))`
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.Application{
+				Arguments: []ast.Expression{
+					ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("connect"))),
+					ast.NewStringLiteral("x"),
+				},
+				Named: []*ast.NamedArgument{
+					{Identifier: "port", Expression: ast.NewIntegerLiteral(80)},
+					{Identifier: "secure", Expression: ast.NewApplication(
+						ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("not"))),
+						ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("insecure"))),
+					)},
+				},
+			},
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestExpressionPipeline(t *testing.T) {
+	source := `xs |> (filter even) |> (map f >> g >> h) |> println`
+
+	name := func(n string) ast.Expression {
+		return ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier(n)))
+	}
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			ast.NewApplication(
+				name("println"),
+				ast.NewApplication(
+					name("map"),
+					ast.NewApplication(name("filter"), name("xs"), name("even")),
+					&ast.Composition{Functions: []ast.Expression{name("f"), name("g"), name("h")}},
+				),
+			),
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestExpressionAssignment(t *testing.T) {
+	source := `total := xs |> len
+	a := b := 1`
+
+	name := func(n string) ast.Expression {
+		return ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier(n)))
+	}
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.Assignment{Target: name("total"), Value: ast.NewApplication(name("len"), name("xs"))},
+			&ast.Assignment{Target: name("a"), Value: &ast.Assignment{Target: name("b"), Value: ast.NewIntegerLiteral(1)}},
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestExpressionLoop(t *testing.T) {
+	source := `for (k, v) in pairs { if v { continue } else { break } k }`
+
+	pattern := ast.Identifier("(k, v)")
+	tuple := ast.NewSelector(ast.NewIdentifierSelector(&pattern))
+
+	name := func(n string) ast.Expression {
+		return ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier(n)))
+	}
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.For{
+				Iterable: name("pairs"),
+				Body: &ast.FunctionLiteral{
+					Parameters: []*ast.Identifier{&pattern},
+					Block:      true,
+					Body: &ast.Scope{
+						Items: []ast.Node{
+							&ast.Definition{Identifier: "k", Expression: &ast.Unpack{Tuple: tuple, Index: 0, Size: 2}},
+							&ast.Definition{Identifier: "v", Expression: &ast.Unpack{Tuple: tuple, Index: 1, Size: 2}},
+							&ast.Conditional{
+								Condition:   name("v"),
+								Consequence: ast.ScopeExpressions(&ast.Continue{}),
+								Alternative: ast.ScopeExpressions(&ast.Break{}),
+							},
+							name("k"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestLoopErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`break`, "break outside of a loop on line 1 column 1"},
+		{`fn f x { continue }`, "continue outside of a loop on line 1 column 10"},
+		{`for x in xs { \y -> break }`, "break outside of a loop on line 1 column 21"},
+		{`for x in xs { fn f { for y in x { y } break } }`, "break outside of a loop on line 1 column 39"},
+		{`for x y in xs { x }`, "expected a name or a tuple pattern to bind the elements to on line 1 column 5"},
+		{`for ...x in xs { x }`, "expected a name or a tuple pattern to bind the elements to on line 1 column 5"},
+		{`for x xs { x }`, "expected a name or a tuple pattern to bind the elements to on line 1 column 5"},
+		{`for x in xs x`, "expected left_brace, but got identifier on line 1 column 13"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(&l)
+
+		_, err := p.Parse()
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestExpressionTry(t *testing.T) {
+	source := `try { (risky) } catch e { e.message } finally { (done) }
+	try { (risky) } finally { (done) }`
+
+	name := func(n string) ast.Expression {
+		return ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier(n)))
+	}
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.Try{
+				Body: ast.ScopeExpressions(ast.NewApplication(name("risky"))),
+				Handler: &ast.FunctionLiteral{
+					Parameters: []*ast.Identifier{ast.NewIdentifier("e")},
+					Block:      true,
+					Body: ast.ScopeExpressions(ast.NewSelector(
+						ast.NewIdentifierSelector(ast.NewIdentifier("e")),
+						ast.NewIdentifierSelector(ast.NewIdentifier("message")),
+					)),
+				},
+				Finalizer: ast.ScopeExpressions(ast.NewApplication(name("done"))),
+			},
+			&ast.Try{
+				Body:      ast.ScopeExpressions(ast.NewApplication(name("risky"))),
+				Finalizer: ast.ScopeExpressions(ast.NewApplication(name("done"))),
+			},
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestTryErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { 1 }`, "expected a catch or a finally after the try on line 1 column 1"},
+		{`try { 1 } catch { 2 }`, "expected identifier, but got left_brace on line 1 column 17"},
+		{`try { 1 } catch (a, b) { 2 }`, "expected identifier, but got left_paren on line 1 column 17"},
+		{`try 1 catch e { 2 }`, "expected left_brace, but got number on line 1 column 5"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(&l)
+
+		_, err := p.Parse()
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestExpressionPropagation(t *testing.T) {
+	source := `fn f s { n: (parse s)? (g n? s.a??) }`
+
+	name := func(n string) ast.Expression {
+		return ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier(n)))
+	}
+
+	field := ast.NewSelector(
+		ast.NewIdentifierSelector(ast.NewIdentifier("s")),
+		ast.NewIdentifierSelector(ast.NewIdentifier("a")),
+	)
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.Definition{
+				Identifier: "f",
+				Expression: &ast.FunctionLiteral{
+					Parameters: []*ast.Identifier{ast.NewIdentifier("s")},
+					Body: &ast.Scope{
+						Items: []ast.Node{
+							&ast.Definition{Identifier: "n", Expression: &ast.Propagate{Expression: ast.NewApplication(name("parse"), name("s"))}},
+							ast.NewApplication(
+								name("g"),
+								&ast.Propagate{Expression: name("n")},
+								&ast.Propagate{Expression: &ast.Propagate{Expression: field}},
+							),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestPropagationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x?`, "? outside of a function on line 1 column 2"},
+		{`(parse s)?`, "? outside of a function on line 1 column 10"},
+		{`for x in xs { x? }`, "? outside of a function on line 1 column 16"},
+		{`try { x } catch e { e? }`, "? outside of a function on line 1 column 22"},
+		{`fn f x -> x (f 1)?`, "? outside of a function on line 1 column 18"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(&l)
+
+		_, err := p.Parse()
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestParameterAndArgumentErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fn f a b: 1 c -> a`, "expected parameter 'c' to have a default value, like the ones before it, on line 1 column 13"},
+		{`fn f ...a b -> a`, "expected the rest parameter to be the last one on line 1 column 11"},
+		{`fn f ...a: [] -> a`, "rest parameter cannot have a default value on line 1 column 10"},
+		{`(f x: 1 2)`, "expected a named argument after named arguments on line 1 column 9"},
+		{`(f x: 1 x: 2)`, "argument 'x' is named more than once in application on line 1 column 9"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(&l)
+
+		_, err := p.Parse()
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, but got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestDestructuringInvalidPattern(t *testing.T) {
+	l := lexer.New(`(a, 1): t`)
+	p := New(&l)
+
+	_, err := p.Parse()
+
+	expected := "expected a name or a tuple of names in the pattern (a, 1) on line 1 column 10"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q, but got %v", expected, err)
+	}
+}
+
+func TestExpressionRecordUpdate(t *testing.T) {
+	source := `{ person.inner | age: 31 } { ...person name: "Ana" ...(defaults) }`
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.RecordLiteral{
+				Base: &ast.Selector{
+					Items: []*ast.SelectorItem{
+						ast.NewIdentifierSelector(ast.NewIdentifier("person")),
+						ast.NewIdentifierSelector(ast.NewIdentifier("inner")),
+					},
+				},
+				Fields: []*ast.RecordField{
+					{Identifier: "age", Expression: ast.NewIntegerLiteral(31)},
+				},
+			},
+			&ast.RecordLiteral{
+				Fields: []*ast.RecordField{
+					{Expression: &ast.Selector{
+						Items: []*ast.SelectorItem{
+							ast.NewIdentifierSelector(ast.NewIdentifier("person")),
+						},
+					}, Spread: true},
+					{Identifier: "name", Expression: ast.NewStringLiteral("Ana")},
+					{Expression: ast.NewApplication(&ast.Selector{
+						Items: []*ast.SelectorItem{
+							ast.NewIdentifierSelector(ast.NewIdentifier("defaults")),
+						},
+					}), Spread: true},
+				},
+			},
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestRecordWithDuplicateFields(t *testing.T) {
+	l := lexer.New(`{ a: 1 b: 2 a: 3 }`)
+	p := New(&l)
+
+	_, err := p.Parse()
+
+	expected := "field 'a' is defined more than once in record on line 1 column 13"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q, but got %v", expected, err)
+	}
+}
+
+func TestExpressionMap(t *testing.T) {
+	source := `%{ "a": 1 2: [x] (f x): 'c' }`
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.MapLiteral{
+				Keys: []ast.Expression{
+					ast.NewStringLiteral("a"),
+					ast.NewIntegerLiteral(2),
+					ast.NewApplication(ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("f"))), ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("x")))),
+				},
+				Values: []ast.Expression{
+					ast.NewIntegerLiteral(1),
+					ast.NewSliceLiteral(ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("x")))),
+					ast.NewCharacterLiteral("c"),
+				},
+			},
 		},
 	}
 
@@ -78,7 +580,7 @@ func TestExpressionArray(t *testing.T) {
 	source := `[3: 1 2 3]`
 
 	expected := ast.Scope{
-		Expressions: []ast.Expression{
+		Items: []ast.Node{
 			ast.NewArrayLiteral(
 				3,
 				ast.NewIntegerLiteral(1),
@@ -95,7 +597,7 @@ func TestExpressionSlice(t *testing.T) {
 	source := `[1 2 3]`
 
 	expected := ast.Scope{
-		Expressions: []ast.Expression{
+		Items: []ast.Node{
 			ast.NewSliceLiteral(
 				ast.NewIntegerLiteral(1),
 				ast.NewIntegerLiteral(2),
@@ -111,7 +613,7 @@ func TestExpressionInvocation(t *testing.T) {
 	source := `(println "Hello, World")`
 
 	expected := ast.Scope{
-		Expressions: []ast.Expression{
+		Items: []ast.Node{
 			ast.NewApplication(
 				ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("println"))),
 				ast.NewStringLiteral("Hello, World"),
@@ -128,8 +630,8 @@ func TestDefinitionWithSingleExpression(t *testing.T) {
 	`
 
 	expected := ast.Scope{
-		Definitions: []*ast.Definition{
-			{
+		Items: []ast.Node{
+			&ast.Definition{
 				Identifier: ast.Identifier("name"),
 				Expression: ast.NewStringLiteral("Tojuro"),
 			},
@@ -145,11 +647,11 @@ func TestDefinitionWithScope(t *testing.T) {
 	`
 
 	expected := ast.Scope{
-		Definitions: []*ast.Definition{
-			{
+		Items: []ast.Node{
+			&ast.Definition{
 				Identifier: ast.Identifier("age"),
 				Expression: &ast.Scope{
-					Expressions: []ast.Expression{
+					Items: []ast.Node{
 						ast.NewIntegerLiteral(24),
 					},
 				},
@@ -160,14 +662,36 @@ func TestDefinitionWithScope(t *testing.T) {
 	parseAndCompare(t, source, &expected)
 }
 
+func TestScopeKeepsSourceOrder(t *testing.T) {
+	source := `
+	(print "a")
+	x: 1
+	(print x)
+	`
+
+	name := func(n string) ast.Expression {
+		return ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier(n)))
+	}
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			ast.NewApplication(name("print"), ast.NewStringLiteral("a")),
+			&ast.Definition{Identifier: "x", Expression: ast.NewIntegerLiteral(1)},
+			ast.NewApplication(name("print"), name("x")),
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
 func TestFunctionDefinitionWithSingleExpression(t *testing.T) {
 	source := `
 	fn add_two x -> (add x 2)
 	`
 
 	expected := ast.Scope{
-		Definitions: []*ast.Definition{
-			{
+		Items: []ast.Node{
+			&ast.Definition{
 				Identifier: ast.Identifier("add_two"),
 				Expression: &ast.FunctionLiteral{
 					Parameters: []*ast.Identifier{
@@ -194,8 +718,8 @@ func TestFunctionDefinitionWithScope(t *testing.T) {
 	`
 
 	expected := ast.Scope{
-		Definitions: []*ast.Definition{
-			{
+		Items: []ast.Node{
+			&ast.Definition{
 				Identifier: ast.Identifier("add_three"),
 				Expression: &ast.FunctionLiteral{
 					Parameters: []*ast.Identifier{
@@ -215,3 +739,42 @@ func TestFunctionDefinitionWithScope(t *testing.T) {
 
 	parseAndCompare(t, source, &expected)
 }
+
+func TestExpressionConditional(t *testing.T) {
+	source := `
+	if flag { 1 } else if other { 2 } else { 3 }
+	`
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.Conditional{
+				Condition:   ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("flag"))),
+				Consequence: ast.ScopeExpressions(ast.NewIntegerLiteral(1)),
+				Alternative: &ast.Conditional{
+					Condition:   ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("other"))),
+					Consequence: ast.ScopeExpressions(ast.NewIntegerLiteral(2)),
+					Alternative: ast.ScopeExpressions(ast.NewIntegerLiteral(3)),
+				},
+			},
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
+
+func TestExpressionLazy(t *testing.T) {
+	source := `lazy (expensive 1)`
+
+	expected := ast.Scope{
+		Items: []ast.Node{
+			&ast.Lazy{
+				Expression: ast.NewApplication(
+					ast.NewSelector(ast.NewIdentifierSelector(ast.NewIdentifier("expensive"))),
+					ast.NewIntegerLiteral(1),
+				),
+			},
+		},
+	}
+
+	parseAndCompare(t, source, &expected)
+}
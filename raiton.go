@@ -0,0 +1,272 @@
+// Package raiton embeds the Raiton interpreter in Go programs.
+//
+// An Interpreter keeps its environment between evaluations, so
+// definitions made by one call to EvalString are visible to the next,
+// the same way they are in the REPL.
+package raiton
+
+import (
+	"context"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"raiton/ast"
+	"raiton/compiler"
+	"raiton/evaluator"
+	"raiton/lexer"
+	"raiton/object"
+	"raiton/optimizer"
+	"raiton/parser"
+)
+
+// Values in lazy mode may wait on each other as deep as calls nest, like
+// an accumulator of a loop which is only added up at the end, so lazy
+// evaluation is limited to this depth unless WithMaxDepth sets another.
+const DefaultLazyMaxDepth = 100000
+
+type Interpreter struct {
+	env      *object.Environment
+	builtins map[string]object.Object
+	stdout   io.Writer
+	stderr   io.Writer
+	maxDepth int // negative unless set with WithMaxDepth
+	maxSteps int
+	timeout  time.Duration
+	lazy     bool
+	compiled bool
+	optimize bool
+	passes   []string
+}
+
+type Option func(i *Interpreter)
+
+// Sets the writer the program's standard output goes to.
+func WithStdout(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.stdout = w
+	}
+}
+
+// Sets the writer the program's standard error goes to.
+func WithStderr(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.stderr = w
+	}
+}
+
+// Limits the depth of nested function calls. Zero means no limit. Without
+// it, there is no limit in eager mode, and DefaultLazyMaxDepth in lazy mode.
+func WithMaxDepth(depth int) Option {
+	return func(i *Interpreter) {
+		i.maxDepth = depth
+	}
+}
+
+// Limits the number of steps a single evaluation can take. Zero means no limit.
+func WithMaxSteps(steps int) Option {
+	return func(i *Interpreter) {
+		i.maxSteps = steps
+	}
+}
+
+// Limits the wall-clock time a single evaluation can take. Zero means no limit.
+func WithTimeout(timeout time.Duration) Option {
+	return func(i *Interpreter) {
+		i.timeout = timeout
+	}
+}
+
+// Evaluates definitions and function arguments only when their value is needed.
+func WithLazyEvaluation() Option {
+	return func(i *Interpreter) {
+		i.lazy = true
+	}
+}
+
+// Compiles programs into closures before running them, which is
+// faster than walking their syntax trees, with the same results.
+func WithCompilation() Option {
+	return func(i *Interpreter) {
+		i.compiled = true
+	}
+}
+
+// Optimizes programs before evaluating them, with the given passes of the
+// optimizer package, or with all of them if none are given.
+func WithOptimization(passes ...string) Option {
+	return func(i *Interpreter) {
+		i.optimize = true
+		i.passes = passes
+	}
+}
+
+// Creates an Interpreter with an empty environment and
+// its own copy of the default builtins.
+func New(opts ...Option) *Interpreter {
+	i := &Interpreter{
+		env:      object.NewEnvironment(),
+		builtins: evaluator.DefaultBuiltins(),
+		maxDepth: -1,
+		stdout:   os.Stdout,
+		stderr:   os.Stderr,
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
+}
+
+// Evaluates the source, returning the value of its last expression.
+func (i *Interpreter) EvalString(source string) (object.Object, error) {
+	return i.EvalStringContext(context.Background(), source)
+}
+
+// Like EvalString, but stops evaluating when the context is done.
+func (i *Interpreter) EvalStringContext(ctx context.Context, source string) (object.Object, error) {
+	l := lexer.New(source)
+	p := parser.New(&l)
+
+	program, err := p.Parse()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return i.eval(ctx, program)
+}
+
+// Reads the file at the given path and evaluates it.
+func (i *Interpreter) EvalFile(path string) (object.Object, error) {
+	return i.EvalFileContext(context.Background(), path)
+}
+
+// Like EvalFile, but stops evaluating when the context is done.
+func (i *Interpreter) EvalFileContext(ctx context.Context, path string) (object.Object, error) {
+	source, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return i.EvalStringContext(ctx, string(source))
+}
+
+// Registers a host function as a builtin under the given name.
+func (i *Interpreter) Register(name string, fn object.BuiltinFunction) {
+	i.builtins[name] = object.MakeBuiltin(fn)
+}
+
+// Registers an arbitrary Go function as a builtin under the given name,
+// converting its arguments and results as described by object.WrapFunc.
+func (i *Interpreter) RegisterFunc(name string, fn any) error {
+	builtin, err := object.WrapFunc(fn)
+
+	if err != nil {
+		return err
+	}
+
+	i.builtins[name] = builtin
+
+	return nil
+}
+
+// Registers host functions as fields of a record bound to the namespace,
+// so they can be called through a selector, like `(strings.upper s)`.
+// Registering into an existing namespace adds to it.
+func (i *Interpreter) RegisterNamespace(namespace string, fns map[string]object.BuiltinFunction) {
+	record, ok := i.builtins[namespace].(*object.Record)
+
+	if !ok {
+		record = object.NewRecord()
+		i.builtins[namespace] = record
+	}
+
+	names := make([]string, 0, len(fns))
+
+	for name := range fns {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		record.Set(name, object.MakeBuiltin(fns[name]))
+	}
+}
+
+// Looks up the value bound to the name in the environment.
+func (i *Interpreter) Get(name string) (object.Object, bool) {
+	return i.env.Lookup(name)
+}
+
+// Binds the value to the name in the environment.
+func (i *Interpreter) Set(name string, value object.Object) {
+	i.env.Define(name, value)
+}
+
+func (i *Interpreter) eval(ctx context.Context, program ast.Node) (object.Object, error) {
+	if i.optimize {
+		var err error
+
+		if program, err = i.optimizeProgram(program); err != nil {
+			return nil, err
+		}
+	}
+
+	maxDepth := i.maxDepth
+
+	if maxDepth < 0 {
+		maxDepth = 0
+
+		if i.lazy {
+			maxDepth = DefaultLazyMaxDepth
+		}
+	}
+
+	opts := []evaluator.Option{
+		evaluator.WithBuiltins(i.builtins),
+		evaluator.WithStdout(i.stdout),
+		evaluator.WithStderr(i.stderr),
+		evaluator.WithMaxDepth(maxDepth),
+		evaluator.WithMaxSteps(i.maxSteps),
+		evaluator.WithTimeout(i.timeout),
+	}
+
+	if i.lazy {
+		opts = append(opts, evaluator.WithLazyEvaluation())
+	}
+
+	var backend evaluator.Backend
+
+	if i.compiled {
+		c := compiler.New(i.env, opts...)
+		backend = &c
+	} else {
+		e := evaluator.New(i.env, opts...)
+		backend = &e
+	}
+
+	return backend.EvaluateContext(ctx, program)
+}
+
+func (i *Interpreter) optimizeProgram(program ast.Node) (ast.Node, error) {
+	opts := []optimizer.Option{
+		optimizer.WithBuiltins(i.builtins),
+		optimizer.WithDefined(func(name string) bool {
+			_, ok := i.env.Lookup(name)
+			return ok
+		}),
+	}
+
+	if len(i.passes) > 0 {
+		opts = append(opts, optimizer.WithPasses(i.passes...))
+	}
+
+	o := optimizer.New(opts...)
+
+	return o.Optimize(program)
+}
@@ -0,0 +1,284 @@
+package raiton
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"raiton/evaluator"
+	"raiton/object"
+)
+
+func TestEvalString(t *testing.T) {
+	interp := New()
+
+	if _, err := interp.EvalString(`fn inc x -> (add x 1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := interp.EvalString(`(inc 41)`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Inspect() != "42" {
+		t.Fatalf("expected 42, but got %s", result.Inspect())
+	}
+}
+
+func TestRegister(t *testing.T) {
+	var stdout bytes.Buffer
+
+	interp := New(WithStdout(&stdout))
+
+	interp.Register("shout", func(rt object.Runtime, args ...object.Object) (object.Object, error) {
+		str, ok := args[0].(*object.String)
+
+		if !ok {
+			return nil, fmt.Errorf("expected a string")
+		}
+
+		fmt.Fprintln(rt.Stdout(), strings.ToUpper(str.Value))
+
+		return str, nil
+	})
+
+	if _, err := interp.EvalString(`(shout "hello")`); err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout.String() != "HELLO\n" {
+		t.Fatalf("expected HELLO on stdout, but got %q", stdout.String())
+	}
+
+	if _, err := New().EvalString(`(shout "hello")`); err == nil {
+		t.Fatalf("expected builtins not to be shared between interpreters")
+	}
+}
+
+func TestRegisterFunc(t *testing.T) {
+	interp := New()
+
+	err := interp.RegisterFunc("repeat", func(s string, n int) string {
+		return strings.Repeat(s, n)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := interp.EvalString(`(repeat "ab" 3)`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Inspect() != `"ababab"` {
+		t.Fatalf("expected \"ababab\", but got %s", result.Inspect())
+	}
+
+	// wrapped functions curry like other builtins
+	result, err = interp.EvalString(`(map [1 2] (repeat "ab"))`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Inspect() != `[2: "ab" "abab"]` {
+		t.Fatalf("expected [2: \"ab\" \"abab\"], but got %s", result.Inspect())
+	}
+}
+
+func TestRegisterNamespace(t *testing.T) {
+	interp := New()
+
+	interp.RegisterNamespace("math", map[string]object.BuiltinFunction{
+		"answer": func(_ object.Runtime, _ ...object.Object) (object.Object, error) {
+			return &object.Integer{Value: 42}, nil
+		},
+	})
+
+	result, err := interp.EvalString(`(math.answer)`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Inspect() != "42" {
+		t.Fatalf("expected 42, but got %s", result.Inspect())
+	}
+}
+
+func TestRegisterNamespaceExtendsDefaults(t *testing.T) {
+	interp := New()
+
+	interp.RegisterNamespace("str", map[string]object.BuiltinFunction{
+		"shout": func(_ object.Runtime, args ...object.Object) (object.Object, error) {
+			return &object.String{Value: "HEY"}, nil
+		},
+	})
+
+	result, err := interp.EvalString(`(concat (str.shout) " " (str.upper "you"))`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Inspect() != `"HEY YOU"` {
+		t.Fatalf(`expected "HEY YOU", but got %s`, result.Inspect())
+	}
+
+	if _, err := New().EvalString(`(str.shout)`); err == nil {
+		t.Fatalf("expected namespaces not to be shared between interpreters")
+	}
+}
+
+func TestExamples(t *testing.T) {
+	var stdout bytes.Buffer
+
+	interp := New(WithStdout(&stdout))
+
+	if _, err := interp.EvalFile("examples/main.rai"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Hello, John\n[3: 2 3 4]\n"
+
+	if stdout.String() != expected {
+		t.Fatalf("expected %q on stdout, but got %q", expected, stdout.String())
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	interp := New()
+
+	interp.Set("x", &object.Integer{Value: 2})
+
+	if _, err := interp.EvalString(`y: (add x 3)`); err != nil {
+		t.Fatal(err)
+	}
+
+	y, ok := interp.Get("y")
+
+	if !ok {
+		t.Fatalf("expected y to be defined")
+	}
+
+	if y.Inspect() != "5" {
+		t.Fatalf("expected 5, but got %s", y.Inspect())
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	interp := New(WithMaxDepth(100))
+
+	_, err := interp.EvalString(`
+	fn forever x -> (add 1 (forever x))
+	(forever 1)
+	`)
+
+	if !errors.Is(err, evaluator.ErrDepthLimit) {
+		t.Fatalf("expected call depth error, but got %v", err)
+	}
+}
+
+func TestLazyMaxDepth(t *testing.T) {
+	source := `
+	fn count n acc {
+	  if (eq n 0) { acc } else { (count (sub n 1) (add acc 1)) }
+	}
+	(count 200000 0)
+	`
+
+	// the accumulator nests deeper than the default limit of lazy mode
+	for _, opts := range [][]Option{{WithLazyEvaluation()}, {WithLazyEvaluation(), WithCompilation()}} {
+		if _, err := New(opts...).EvalString(source); !errors.Is(err, evaluator.ErrDepthLimit) {
+			t.Fatalf("expected call depth error, but got %v", err)
+		}
+	}
+
+	result, err := New().EvalString(source)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Inspect() != "200000" {
+		t.Fatalf("expected 200000, but got %s", result.Inspect())
+	}
+}
+
+// Runs every program of the conformance corpus with each backend, eagerly
+// and lazily. A program starts with a comment stating either the expected
+// result, like `# expect: 42`, or a part of the expected error message,
+// like `# error: not defined`.
+func TestConformance(t *testing.T) {
+	paths, err := filepath.Glob("testdata/conformance/*.rai")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backends := []struct {
+		name string
+		opts []Option
+	}{
+		{"evaluator", []Option{}},
+		{"compiler", []Option{WithCompilation()}},
+		{"evaluator/lazy", []Option{WithLazyEvaluation()}},
+		{"compiler/lazy", []Option{WithCompilation(), WithLazyEvaluation()}},
+		{"evaluator/optimized", []Option{WithOptimization()}},
+		{"compiler/optimized", []Option{WithCompilation(), WithOptimization()}},
+	}
+
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		header, _, _ := strings.Cut(string(source), "\n")
+		expected, expectError := strings.CutPrefix(header, "# error: ")
+
+		if !expectError {
+			var ok bool
+
+			if expected, ok = strings.CutPrefix(header, "# expect: "); !ok {
+				t.Fatalf("%s: expected an `# expect:` or `# error:` header", path)
+			}
+		}
+
+		for _, backend := range backends {
+			name := fmt.Sprintf("%s/%s", strings.TrimSuffix(filepath.Base(path), ".rai"), backend.name)
+
+			t.Run(name, func(t *testing.T) {
+				opts := append(backend.opts, WithMaxDepth(1000))
+				interp := New(opts...)
+
+				result, err := interp.EvalString(string(source))
+
+				if expectError {
+					if err == nil || !strings.Contains(err.Error(), expected) {
+						t.Fatalf("expected error containing %q, but got %v", expected, err)
+					}
+
+					return
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if result.Inspect() != expected {
+					t.Fatalf("expected %s, but got %s", expected, result.Inspect())
+				}
+			})
+		}
+	}
+}
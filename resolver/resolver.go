@@ -0,0 +1,332 @@
+package resolver
+
+import (
+	"raiton/ast"
+)
+
+// Resolver assigns slots to the parameters and local definitions of every
+// function and binds references to them, so they can be looked up by index
+// instead of by name. Definitions outside of functions and references to
+// names which are not local to any enclosing function stay unresolved, and
+// are looked up by name, which keeps them redefinable.
+type Resolver struct {
+	frames []*frame
+}
+
+type frame struct {
+	slots  map[ast.Identifier]int
+	locals []ast.Identifier
+}
+
+func New() Resolver {
+	return Resolver{}
+}
+
+// Resolves the node in place. Resolving a node again has no effect.
+func (r *Resolver) Resolve(node ast.Node) error {
+	return node.Accept(r)
+}
+
+func (r *Resolver) pushFrame() *frame {
+	f := &frame{
+		slots:  map[ast.Identifier]int{},
+		locals: []ast.Identifier{},
+	}
+	r.frames = append(r.frames, f)
+	return f
+}
+
+func (r *Resolver) popFrame() {
+	r.frames = r.frames[:len(r.frames)-1]
+}
+
+// Declares the name in the innermost frame, returning its binding,
+// or nil outside of functions.
+func (r *Resolver) declare(name ast.Identifier) *ast.Binding {
+	if len(r.frames) == 0 {
+		return nil
+	}
+
+	f := r.frames[len(r.frames)-1]
+
+	index, ok := f.slots[name]
+
+	if !ok {
+		index = len(f.locals)
+		f.slots[name] = index
+		f.locals = append(f.locals, name)
+	}
+
+	return &ast.Binding{Depth: 0, Index: index}
+}
+
+// Finds the binding of the name, or nil if it has not been
+// declared in any of the enclosing functions (yet).
+func (r *Resolver) lookup(name ast.Identifier) *ast.Binding {
+	for depth := 0; depth < len(r.frames); depth++ {
+		f := r.frames[len(r.frames)-1-depth]
+
+		if index, ok := f.slots[name]; ok {
+			return &ast.Binding{Depth: depth, Index: index}
+		}
+	}
+
+	return nil
+}
+
+/*** Visitor Methods ***/
+
+func (r *Resolver) VisitScope(n *ast.Scope) error {
+	for _, item := range n.Items {
+		if err := item.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitDefinition(n *ast.Definition) error {
+	// declared before the expression is resolved,
+	// so local functions can refer to themselves
+	n.Binding = r.declare(n.Identifier)
+
+	return n.Expression.Accept(r)
+}
+
+func (r *Resolver) VisitIdentifier(n *ast.Identifier) error {
+	return nil
+}
+
+func (r *Resolver) VisitSelector(n *ast.Selector) error {
+	if len(n.Items) > 0 && n.Items[0].Identifier != nil {
+		n.Binding = r.lookup(*n.Items[0].Identifier)
+	}
+
+	for _, item := range n.Items {
+		if err := item.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitSelectorItem(n *ast.SelectorItem) error {
+	if n.Expression != nil {
+		if err := n.Expression.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	if n.End != nil {
+		return n.End.Accept(r)
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitApplication(n *ast.Application) error {
+	for _, a := range n.Arguments {
+		if err := a.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range n.Named {
+		if err := a.Expression.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitConditional(n *ast.Conditional) error {
+	if err := n.Condition.Accept(r); err != nil {
+		return err
+	}
+
+	if err := n.Consequence.Accept(r); err != nil {
+		return err
+	}
+
+	return n.Alternative.Accept(r)
+}
+
+func (r *Resolver) VisitLazy(n *ast.Lazy) error {
+	return n.Expression.Accept(r)
+}
+
+func (r *Resolver) VisitFor(n *ast.For) error {
+	if err := n.Iterable.Accept(r); err != nil {
+		return err
+	}
+
+	return n.Body.Accept(r)
+}
+
+func (r *Resolver) VisitBreak(n *ast.Break) error {
+	return nil
+}
+
+func (r *Resolver) VisitContinue(n *ast.Continue) error {
+	return nil
+}
+
+func (r *Resolver) VisitPropagate(n *ast.Propagate) error {
+	return n.Expression.Accept(r)
+}
+
+func (r *Resolver) VisitAssignment(n *ast.Assignment) error {
+	if err := n.Target.Accept(r); err != nil {
+		return err
+	}
+
+	return n.Value.Accept(r)
+}
+
+func (r *Resolver) VisitComposition(n *ast.Composition) error {
+	for _, expr := range n.Functions {
+		if err := expr.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitTry(n *ast.Try) error {
+	if err := n.Body.Accept(r); err != nil {
+		return err
+	}
+
+	if n.Handler != nil {
+		if err := n.Handler.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	if n.Finalizer != nil {
+		return n.Finalizer.Accept(r)
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitFunction(n *ast.FunctionLiteral) error {
+	f := r.pushFrame()
+	defer r.popFrame()
+
+	for _, p := range n.Parameters {
+		r.declare(*p)
+	}
+
+	// defaults are evaluated in the function's frame, once the
+	// parameters before them are bound
+	for _, d := range n.Defaults {
+		if d == nil {
+			continue
+		}
+
+		if err := d.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	if err := n.Body.Accept(r); err != nil {
+		return err
+	}
+
+	n.Locals = f.locals
+
+	return nil
+}
+
+func (r *Resolver) VisitRecord(n *ast.RecordLiteral) error {
+	if n.Base != nil {
+		if err := n.Base.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range n.Fields {
+		if err := field.Expression.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitMap(n *ast.MapLiteral) error {
+	for i, key := range n.Keys {
+		if err := key.Accept(r); err != nil {
+			return err
+		}
+
+		if err := n.Values[i].Accept(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitArray(n *ast.ArrayLiteral) error {
+	for _, expr := range n.Elements {
+		if err := expr.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitSlice(n *ast.SliceLiteral) error {
+	for _, expr := range n.Elements {
+		if err := expr.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitTuple(n *ast.TupleLiteral) error {
+	for _, expr := range n.Elements {
+		if err := expr.Accept(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitUnpack(n *ast.Unpack) error {
+	return n.Tuple.Accept(r)
+}
+
+func (r *Resolver) VisitInteger(n *ast.IntegerLiteral) error {
+	return nil
+}
+
+func (r *Resolver) VisitBigInteger(n *ast.BigIntegerLiteral) error {
+	return nil
+}
+
+func (r *Resolver) VisitFloat(n *ast.FloatLiteral) error {
+	return nil
+}
+
+func (r *Resolver) VisitString(n *ast.StringLiteral) error {
+	return nil
+}
+
+func (r *Resolver) VisitCharacter(n *ast.CharacterLiteral) error {
+	return nil
+}
+
+func (r *Resolver) VisitBoolean(n *ast.BooleanLiteral) error {
+	return nil
+}
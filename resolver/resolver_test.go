@@ -0,0 +1,66 @@
+package resolver
+
+import (
+	"testing"
+
+	"raiton/ast"
+	"raiton/lexer"
+	"raiton/parser"
+)
+
+func resolve(t *testing.T, source string) *ast.Scope {
+	l := lexer.New(source)
+	p := parser.New(&l)
+	program, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("parse error: %s", err)
+	}
+
+	r := New()
+
+	if err := r.Resolve(program); err != nil {
+		t.Fatalf("resolve error: %s", err)
+	}
+
+	return program.(*ast.Scope)
+}
+
+func expectBinding(t *testing.T, what string, got *ast.Binding, expected *ast.Binding) {
+	if expected == nil && got != nil {
+		t.Errorf("%s: expected no binding, but got %+v", what, *got)
+	} else if expected != nil && (got == nil || *got != *expected) {
+		t.Errorf("%s: expected binding %+v, but got %v", what, *expected, got)
+	}
+}
+
+func TestResolveFunction(t *testing.T) {
+	program := resolve(t, `
+	x: 1
+	fn outer a {
+		b: (add a x)
+		\c -> (add b c)
+	}
+	`)
+
+	expectBinding(t, "global definition", program.Items[0].(*ast.Definition).Binding, nil)
+
+	outer := program.Items[1].(*ast.Definition).Expression.(*ast.FunctionLiteral)
+
+	if len(outer.Locals) != 2 || outer.Locals[0] != "a" || outer.Locals[1] != "b" {
+		t.Fatalf("expected locals [a b], but got %v", outer.Locals)
+	}
+
+	b := outer.Body.Items[0].(*ast.Definition)
+	expectBinding(t, "local definition", b.Binding, &ast.Binding{Depth: 0, Index: 1})
+
+	sum := b.Expression.(*ast.Application)
+	expectBinding(t, "parameter", sum.Arguments[1].(*ast.Selector).Binding, &ast.Binding{Depth: 0, Index: 0})
+	expectBinding(t, "global", sum.Arguments[2].(*ast.Selector).Binding, nil)
+	expectBinding(t, "builtin", sum.Arguments[0].(*ast.Selector).Binding, nil)
+
+	inner := outer.Body.Items[1].(*ast.FunctionLiteral)
+	innerSum := inner.Body.Items[0].(*ast.Application)
+	expectBinding(t, "captured", innerSum.Arguments[1].(*ast.Selector).Binding, &ast.Binding{Depth: 1, Index: 1})
+	expectBinding(t, "inner parameter", innerSum.Arguments[2].(*ast.Selector).Binding, &ast.Binding{Depth: 0, Index: 0})
+}
@@ -23,25 +23,44 @@ func (t *Token) Print(w io.Writer) {
 }
 
 var KEYWORDS = map[string]TokenType{
-	"true":  BOOLEAN,
-	"false": BOOLEAN,
-	"fn":    FUNCTION,
+	"true":     BOOLEAN,
+	"false":    BOOLEAN,
+	"fn":       FUNCTION,
+	"if":       IF,
+	"else":     ELSE,
+	"lazy":     LAZY,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
 }
 
 var SYMBOLS = map[string]TokenType{
-	"(":  OPEN_PAREN,
-	")":  CLOSED_PAREN,
-	"[":  OPEN_BRACKET,
-	"]":  CLOSED_BRACKET,
-	"{":  OPEN_BRACE,
-	"}":  CLOSED_BRACE,
-	"'":  SINGLE_QUOTE,
-	"\"": DOUBLE_QUOTE,
-	":":  COLON,
-	"\\": BACKSLASH,
-	"-":  MINUS,
-	".":  DOT,
-	"->": ARROW,
+	"(":   OPEN_PAREN,
+	")":   CLOSED_PAREN,
+	"[":   OPEN_BRACKET,
+	"]":   CLOSED_BRACKET,
+	"{":   OPEN_BRACE,
+	"%{":  OPEN_MAP,
+	"}":   CLOSED_BRACE,
+	"'":   SINGLE_QUOTE,
+	"\"":  DOUBLE_QUOTE,
+	":":   COLON,
+	":=":  ASSIGN,
+	",":   COMMA,
+	"\\":  BACKSLASH,
+	"-":   MINUS,
+	".":   DOT,
+	"..":  DOT_DOT,
+	"->":  ARROW,
+	"|":   BAR,
+	"|>":  PIPE,
+	">>":  COMPOSE,
+	"...": SPREAD,
+	"?":   QUESTION,
 }
 
 const (
@@ -50,6 +69,16 @@ const (
 	NUMBER     = "number"
 	BOOLEAN    = "boolean"
 	FUNCTION   = "function"
+	IF         = "if"
+	ELSE       = "else"
+	LAZY       = "lazy"
+	FOR        = "for"
+	IN         = "in"
+	BREAK      = "break"
+	CONTINUE   = "continue"
+	TRY        = "try"
+	CATCH      = "catch"
+	FINALLY    = "finally"
 
 	OPEN_PAREN     = "left_paren"
 	CLOSED_PAREN   = "right_paren"
@@ -57,14 +86,23 @@ const (
 	CLOSED_BRACKET = "right_bracket"
 	OPEN_BRACE     = "left_brace"
 	CLOSED_BRACE   = "right_brace"
+	OPEN_MAP       = "left_map"
 
 	SINGLE_QUOTE = "single_quote"
 	DOUBLE_QUOTE = "double_quote"
 	COLON        = "colon"
+	ASSIGN       = "assign"
+	COMMA        = "comma"
 	BACKSLASH    = "backslash"
 	MINUS        = "minus"
 	DOT          = "dot"
+	DOT_DOT      = "dot_dot"
 	ARROW        = "arrow"
+	BAR          = "bar"
+	PIPE         = "pipe"
+	COMPOSE      = "compose"
+	SPREAD       = "spread"
+	QUESTION     = "question"
 
 	EOF     = "eof"
 	ILLEGAL = "illegal"
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"raiton"
+)
+
+func main() {
+	if os.Getenv("SMALLSTACK") != "" {
+		debug.SetMaxStack(8 << 20)
+	}
+	opts := []raiton.Option{}
+	for _, a := range os.Args[2:] {
+		switch a {
+		case "lazy":
+			opts = append(opts, raiton.WithLazyEvaluation())
+		case "compile":
+			opts = append(opts, raiton.WithCompilation())
+		}
+	}
+	r, err := raiton.New(opts...).EvalString(os.Args[1])
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(r.Inspect())
+}